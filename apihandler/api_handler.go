@@ -86,7 +86,8 @@ func NewAPIHandler(writeCachePool *mempool.Mempool[*cache.WriteCache]) *APIHandl
 		apimultiprocess.NewMultiprocessHandler(api),
 		apicontainer.NewBaseHandlers(api),
 		apicumulative.NewU256CumulativeHandler(api),
-		// cumulativei256.NewInt256CumulativeHandlers(api),
+		apicumulative.NewInt256CumulativeHandler(api),
+		apicumulative.NewUint64CumulativeHandler(api),
 		apiruntime.NewRuntimeHandlers(api),
 	}
 
@@ -133,6 +134,23 @@ func (this *APIHandler) Cascade() intf.EthApiRouter {
 	return api.SetWriteCache(writeCache.SetReadOnlyBackend(this.localCache))
 }
 
+// CascadeTo resets the target APIHandler and makes it a cascaded one of the current APIHandler, just like Cascade().
+// The target keeps its handlers, so there is no need to create them again. The write cache is always a new one, because
+// the transitions exported from the old one may still be in use.
+func (this *APIHandler) CascadeTo(target intf.EthApiRouter) intf.EthApiRouter {
+	api := target.(*APIHandler)
+	api.SetDeployer(this.deployer)
+	api.SetEU(nil)
+	api.depth = this.depth + 1
+	api.schedule = this.schedule
+	api.serialNums = [4]uint64{}
+	api.logs = nil
+	clear(api.auxDict)
+
+	writeCache := cache.NewWriteCache(this.localCache, 32, 1)
+	return api.SetWriteCache(writeCache.SetReadOnlyBackend(this.localCache))
+}
+
 func (this *APIHandler) AuxDict() map[string]any { return this.auxDict }
 func (this *APIHandler) WriteCachePool() any     { return this.writeCachePool }
 
@@ -18,10 +18,8 @@
 package api
 
 import (
-	"encoding/binary"
 	"encoding/hex"
 	"math"
-	"math/big"
 
 	"github.com/arcology-network/common-lib/codec"
 	"github.com/arcology-network/common-lib/common"
@@ -36,7 +34,6 @@ import (
 	stgcommon "github.com/arcology-network/storage-committer/common"
 	cache "github.com/arcology-network/storage-committer/storage/cache"
 	commutative "github.com/arcology-network/storage-committer/type/commutative"
-	noncommutative "github.com/arcology-network/storage-committer/type/noncommutative"
 	evmcommon "github.com/ethereum/go-ethereum/common"
 
 	"github.com/holiman/uint256"
@@ -64,12 +61,23 @@ func (this *BaseHandlers) Call(caller, callee [20]byte, input []byte, origin [20
 	// Real handlers
 	signature := codec.Bytes4{}.FromBytes(input)
 
+	// Only the reads are allowed in a STATICCALL, the eval calls are checked in dispatch.
+	if isFromStaticCall && signature != [4]byte{0xc7, 0x67, 0xf3, 0x6f} && signature != [4]byte{0x5a, 0x82, 0xac, 0x21} {
+		return []byte{}, false, eucommon.GAS_CALL_UNKNOW
+	}
+
 	switch signature {
 	case [4]byte{0xb6, 0x13, 0x00, 0x2b}: // b6 13 00 2b
 		return this.new(caller, input[4:]) // Create a new container
 
+	case [4]byte{0x0f, 0x4f, 0x0f, 0xa0}:
+		return this.newWithComparator(caller, input[4:]) // Create a new container with a comparator for the ordered queries
+
 	case [4]byte{0xc7, 0x67, 0xf3, 0x6f}:
 		return this.eval(caller, callee, input[4:], origin, nonce, isFromStaticCall)
+
+	case [4]byte{0x5a, 0x82, 0xac, 0x21}:
+		return this.evalOf(caller, callee, input[4:], origin, nonce, isFromStaticCall) // Read a container of another contract
 	}
 
 	// Custom function call. The base handler may have a custom function to call..
@@ -85,12 +93,39 @@ func (this *BaseHandlers) Api() intf.EthApiRouter { return this.api }
 
 func (this *BaseHandlers) eval(caller, callee [20]byte, input []byte, origin [20]byte, nonce uint64, isFromStaticCall bool) ([]byte, bool, int64) {
 	subInput, err := abi.DecodeTo(input, 2, []byte{}, 1, math.MaxInt)
-	if err != nil || len(subInput) == 0 {
+	if err != nil || len(subInput) < 4 {
 		return []byte{}, false, 0 // Fee has to be 0. Since all the calls will enter here.
 	}
 
-	signature := codec.Bytes4{}.FromBytes(subInput[:4]) // Get the function signature
-	input = subInput
+	return this.dispatch(caller, callee, subInput, origin, nonce, isFromStaticCall)
+}
+
+// Evaluate a read-only function call on the container owned by another contract. The reads are recorded
+// the same way as the ones from the owner, but the writes are only allowed through eval by the owner itself.
+func (this *BaseHandlers) evalOf(caller, callee [20]byte, input []byte, origin [20]byte, nonce uint64, isFromStaticCall bool) ([]byte, bool, int64) {
+	owner, err := abi.DecodeTo(input, 0, [20]byte{}, 1, 32)
+	if err != nil {
+		return []byte{}, false, eucommon.GAS_DECODE
+	}
+
+	subInput, err := abi.DecodeTo(input, 1, []byte{}, 2, math.MaxInt)
+	if err != nil || len(subInput) < 4 || !readOnlySelectors[codec.Bytes4{}.FromBytes(subInput[:4])] {
+		return []byte{}, false, eucommon.GAS_DECODE // Not a read-only function call
+	}
+
+	if !this.api.WriteCache().(*cache.WriteCache).IfExists(this.pathBuilder.Key(owner)) {
+		return []byte{}, false, eucommon.GAS_DECODE + eucommon.GAS_READ // The owner doesn't have a container
+	}
+
+	ret, successful, gas := this.dispatch(owner, callee, subInput, origin, nonce, isFromStaticCall)
+	return ret, successful, gas + eucommon.GAS_DECODE
+}
+
+func (this *BaseHandlers) dispatch(caller, callee [20]byte, input []byte, origin [20]byte, nonce uint64, isFromStaticCall bool) ([]byte, bool, int64) {
+	signature := codec.Bytes4{}.FromBytes(input[:4]) // Get the function signature
+	if isFromStaticCall && !readOnlySelectors[signature] {
+		return []byte{}, false, eucommon.GAS_CALL_UNKNOW // State changes aren't allowed in a STATICCALL
+	}
 
 	switch signature {
 	case [4]byte{0xb4, 0xa1, 0x05, 0x4c}:
@@ -114,11 +149,17 @@ func (this *BaseHandlers) eval(caller, callee [20]byte, input []byte, origin [20
 	case [4]byte{0x2d, 0x88, 0x3a, 0x73}:
 		return this.getByIndex(caller, input[4:]) // Get the element by its key.
 
-	case [4]byte{0xf8, 0x89, 0x79, 0x45}:
-		return this.min(caller, input[4:]) // Delete the element by its key.
+	case [4]byte{0xf5, 0x44, 0x1d, 0x17}:
+		return this.getRange(caller, input[4:]) // Get the keys and the elements in a range of indices.
+
+	case [4]byte{0x98, 0x5c, 0x03, 0x5f}:
+		return this.getRangeByKey(caller, input[4:]) // Get the keys and the elements starting from a key.
 
-	case [4]byte{0x6a, 0xc5, 0xdb, 0x19}:
-		return this.max(caller, input[4:]) // Delete the element by its key.
+	case [4]byte{0xf8, 0x89, 0x79, 0x45}, [4]byte{0x80, 0xbb, 0x99, 0xb8}:
+		return this.min(caller, input[4:]) // Get the minimum element, with an optional comparator.
+
+	case [4]byte{0x6a, 0xc5, 0xdb, 0x19}, [4]byte{0xc2, 0xe8, 0x91, 0x8e}:
+		return this.max(caller, input[4:]) // Get the maximum element, with an optional comparator.
 
 	case [4]byte{0xe5, 0xe2, 0x14, 0xb5}:
 		return this.init(caller, input[4:]) // Set the bounds of the elements in the container.
@@ -131,6 +172,15 @@ func (this *BaseHandlers) eval(caller, callee [20]byte, input []byte, origin [20
 
 	case [4]byte{0x55, 0x46, 0x09, 0xea}:
 		return this.delByKey(caller, input[4:]) // Delete the element by its key.
+
+	case [4]byte{0xad, 0xac, 0x76, 0xd7}:
+		return this.setBatch(caller, input[4:]) // Set the elements by their keys.
+
+	case [4]byte{0x70, 0x60, 0x3c, 0xee}:
+		return this.getBatch(caller, input[4:]) // Get the elements by their keys.
+
+	case [4]byte{0x08, 0x24, 0x8b, 0xe5}:
+		return this.delBatch(caller, input[4:]) // Delete the elements by their keys.
 	//
 	case [4]byte{0x94, 0x42, 0x8e, 0x6a}:
 		return this.resetByKey(caller, input[4:]) // Delete the element by its key.
@@ -141,6 +191,18 @@ func (this *BaseHandlers) eval(caller, callee [20]byte, input []byte, origin [20
 	case [4]byte{0x0b, 0xe0, 0xc6, 0xd5}:
 		return this.delLast(caller, input[4:]) // shrink the size of the container by one
 
+	case [4]byte{0x28, 0x45, 0x8d, 0xf7}:
+		return this.lowerBound(caller, input[4:]) // Get the first element not less than the value, scanning the container.
+
+	case [4]byte{0x3e, 0x8c, 0xd2, 0x17}:
+		return this.upperBound(caller, input[4:]) // Get the first element greater than the value, scanning the container.
+
+	case [4]byte{0xd2, 0xcf, 0x64, 0xfb}:
+		return this.popMin(caller, input[4:]) // Remove the smallest element, scanning the container.
+
+	case [4]byte{0x02, 0xa0, 0x8b, 0xaf}:
+		return this.popMax(caller, input[4:]) // Remove the largest element, scanning the container.
+
 	case [4]byte{0x52, 0xef, 0xea, 0x6e}:
 		return this.clear(caller, input[4:]) // Clear the container.
 
@@ -155,8 +217,8 @@ func (this *BaseHandlers) new(caller evmcommon.Address, input []byte) ([]byte, b
 	gasMeter := eucommon.NewGasMeter()
 
 	elemTypeID, err := abi.Decode(input, 0, uint8(0), 1, 32)
-	gasMeter.Use(0, 0, eucommon.GAS_DECODE) // Gas for decoding the transient flag
-	if err != nil {
+	gasMeter.Use(0, 0, eucommon.GAS_DECODE) // Gas for decoding the element type
+	if err != nil || newTypedElem(elemTypeID.(uint8)) == nil {
 		return []byte{}, false, gasMeter.TotalGasUsed
 	}
 
@@ -316,28 +378,17 @@ func (this *BaseHandlers) getByKey(caller evmcommon.Address, input []byte) ([]by
 	typeID := this.pathBuilder.GetPathType(caller) // Get the type of the container
 	gasMeter.Use(0, 0, eucommon.GAS_GET_RUNTIME_INFO)
 
-	var typedV any
-	switch typeID {
-	case noncommutative.BYTES: // Commutative container
-		typedV = new(noncommutative.Bytes)
-	case commutative.UINT256: // Commutative container
-		typedV = new(commutative.U256)
-	case noncommutative.INT64:
-		typedV = new(noncommutative.Int64)
+	typedV := newTypedElem(typeID)
+	if typedV == nil {
+		return []byte{}, false, gasMeter.TotalGasUsed // Unsupported element type
 	}
 
 	v, _, readDataSize := this.GetByKey(path+str, typedV)
 	gasMeter.Use(uint64(readDataSize), 0, 0)
 
 	if v != nil {
-		// special decoder for byte array
-		fun := func(v any) ([]byte, bool, error) {
-			b, ok := v.([]byte)
-			return b, ok, nil
-		}
-
 		gasMeter.Use(0, 0, eucommon.GAS_ENCODE) // Gas for encoding the value
-		if encoded, err := abi.Encode(v, fun); err == nil {
+		if encoded, err := encodeElem(typeID, v); err == nil {
 			return encoded, true, gasMeter.TotalGasUsed
 		}
 	}
@@ -362,6 +413,65 @@ func (this *BaseHandlers) getByIndex(caller evmcommon.Address, input []byte) ([]
 	return data, successful, readDataSize + gasMeter.TotalGasUsed // Get the value by its key.
 }
 
+// Get the keys and the elements in [start, start + count) in one call.
+func (this *BaseHandlers) getRange(caller evmcommon.Address, input []byte) ([]byte, bool, int64) {
+	gasMeter := eucommon.NewGasMeter()
+	path := this.pathBuilder.Key(caller) // Container path
+	gasMeter.Use(0, 0, eucommon.GAS_GET_CONTAINER_META)
+
+	start, err := abi.DecodeTo(input, 0, uint64(0), 1, 32)
+	gasMeter.Use(0, 0, eucommon.GAS_DECODE) // Gas for decoding the start index
+	if err != nil {
+		return []byte{}, false, gasMeter.TotalGasUsed
+	}
+
+	count, err := abi.DecodeTo(input, 1, uint64(0), 1, 32)
+	gasMeter.Use(0, 0, eucommon.GAS_DECODE) // Gas for decoding the count
+	if err != nil {
+		return []byte{}, false, gasMeter.TotalGasUsed
+	}
+	return this.encodeRange(path, start, count, gasMeter)
+}
+
+// Get the keys and the elements starting from the element of a key in one call.
+func (this *BaseHandlers) getRangeByKey(caller evmcommon.Address, input []byte) ([]byte, bool, int64) {
+	gasMeter := eucommon.NewGasMeter()
+	path := this.pathBuilder.Key(caller) // Container path
+	gasMeter.Use(0, 0, eucommon.GAS_GET_CONTAINER_META)
+
+	key, err := abi.DecodeTo(input, 0, []byte{}, 2, math.MaxInt)
+	gasMeter.Use(0, 0, eucommon.GAS_DECODE) // Gas for decoding the key
+	if err != nil || len(key) == 0 {
+		return []byte{}, false, gasMeter.TotalGasUsed
+	}
+
+	count, err := abi.DecodeTo(input, 1, uint64(0), 1, 32)
+	gasMeter.Use(0, 0, eucommon.GAS_DECODE) // Gas for decoding the count
+	if err != nil {
+		return []byte{}, false, gasMeter.TotalGasUsed
+	}
+
+	start, dataSize := this.IndexOf(path, hex.EncodeToString(key))
+	gasMeter.Use(uint64(dataSize), 0, 0) // Gas for reading the index
+	if start == math.MaxUint64 {
+		return []byte{}, false, gasMeter.TotalGasUsed // The key doesn't exist
+	}
+	return this.encodeRange(path, start, count, gasMeter)
+}
+
+// Read the range and encode the keys and the elements as (bytes[], bytes[]), each element read is charged.
+func (this *BaseHandlers) encodeRange(path string, start, count uint64, gasMeter *eucommon.GasMeter) ([]byte, bool, int64) {
+	keys, values, successful, dataSize := this.ReadRange(path, start, count)
+	gasMeter.Use(uint64(dataSize), 0, eucommon.GAS_READ*int64(len(values))) // Gas for reading the elements
+	if !successful {
+		return []byte{}, false, gasMeter.TotalGasUsed
+	}
+
+	encoded, err := EncodeRange(keys, values)
+	gasMeter.Use(0, 0, eucommon.GAS_ENCODE*int64(len(values)+1)) // Gas for encoding the elements
+	return encoded, err == nil, gasMeter.TotalGasUsed
+}
+
 // Push a new element into the container. If the key does not exist, it will be created and the value will be set.
 func (this *BaseHandlers) setByKey(caller evmcommon.Address, input []byte) ([]byte, bool, int64) {
 	gasMeter := eucommon.NewGasMeter()
@@ -386,27 +496,10 @@ func (this *BaseHandlers) setByKey(caller evmcommon.Address, input []byte) ([]by
 	typeID := this.pathBuilder.GetPathType(caller) // Get the type of the container
 
 	// Other types
-	var val any
-	switch typeID {
-	case commutative.UINT256: // Commutative container
-		// Decode the input delta value, could be negative or positive.
-		var v *big.Int
-		if v, err = abi.DecodeInt256(valueBytes); err != nil {
-			gasMeter.Use(0, 0, eucommon.GAS_DECODE) // Gas for decoding the value
-			return []byte{}, false, gasMeter.TotalGasUsed
-		}
-
-		// Get the bytes from the delta bytes and Create a new delta value for the element
-		delta := new(uint256.Int).SetBytes(new(big.Int).Abs(v).Bytes())
-		val = commutative.NewU256Delta(delta, v.Sign() >= 0)
-
-	case noncommutative.INT64:
-		bytes := make([]byte, 8)
-		copy(bytes, valueBytes) // valueBytes could be less than 8 bytes, so we need to copy it to the bytes array
-		val = noncommutative.NewInt64(int64(binary.LittleEndian.Uint64(bytes)))
-
-	case noncommutative.BYTES:
-		val = noncommutative.NewBytes(valueBytes) // Non-commutative container by default
+	val, err := newElem(typeID, valueBytes)
+	if err != nil {
+		gasMeter.Use(0, 0, eucommon.GAS_DECODE) // Gas for decoding the value
+		return []byte{}, false, gasMeter.TotalGasUsed
 	}
 
 	successful, writeDataSize := this.SetByKey(path+hex.EncodeToString(key), val)
@@ -429,6 +522,102 @@ func (this *BaseHandlers) delByKey(caller evmcommon.Address, input []byte) ([]by
 	return []byte{}, false, gasMeter.TotalGasUsed
 }
 
+// Set the elements by their keys, the path and the type are resolved only once for all the elements.
+func (this *BaseHandlers) setBatch(caller evmcommon.Address, input []byte) ([]byte, bool, int64) {
+	gasMeter := eucommon.NewGasMeter()
+	path := this.pathBuilder.Key(caller) // Container path
+
+	arrays, err := DecodeBytesArrays(input, 2)
+	gasMeter.Use(0, 0, eucommon.GAS_DECODE) // Gas for decoding the keys and the values
+	if err != nil || len(arrays[0]) != len(arrays[1]) {
+		return []byte{}, false, gasMeter.TotalGasUsed
+	}
+
+	typeID := this.pathBuilder.GetPathType(caller) // Get the type of the container
+	gasMeter.Use(0, 0, eucommon.GAS_GET_RUNTIME_INFO)
+
+	keys, values := arrays[0], arrays[1]
+	flags := make([]bool, len(keys))
+	for i := range keys {
+		val, err := newElem(typeID, values[i])
+		gasMeter.Use(0, 0, eucommon.GAS_DECODE) // Gas for decoding the value
+		if err != nil {
+			continue
+		}
+
+		successful, writeDataSize := this.SetByKey(path+hex.EncodeToString(keys[i]), val)
+		gasMeter.Use(0, writeDataSize, 0) // Gas for writing the value
+		flags[i] = successful
+	}
+
+	encoded, err := EncodeBatch(flags)
+	gasMeter.Use(0, 0, eucommon.GAS_ENCODE) // Gas for encoding the flags
+	return encoded, err == nil, gasMeter.TotalGasUsed
+}
+
+// Get the elements by their keys, a missing element has an empty value and its flag is false.
+func (this *BaseHandlers) getBatch(caller evmcommon.Address, input []byte) ([]byte, bool, int64) {
+	gasMeter := eucommon.NewGasMeter()
+	path := this.pathBuilder.Key(caller) // Container path
+	gasMeter.Use(0, 0, eucommon.GAS_CONTAINER_META)
+
+	arrays, err := DecodeBytesArrays(input, 1)
+	gasMeter.Use(0, 0, eucommon.GAS_DECODE) // Gas for decoding the keys
+	if err != nil {
+		return []byte{}, false, gasMeter.TotalGasUsed
+	}
+
+	typeID := this.pathBuilder.GetPathType(caller) // Get the type of the container
+	gasMeter.Use(0, 0, eucommon.GAS_GET_RUNTIME_INFO)
+	if newTypedElem(typeID) == nil {
+		return []byte{}, false, gasMeter.TotalGasUsed // Unsupported element type
+	}
+
+	keys := arrays[0]
+	flags, values := make([]bool, len(keys)), make([][]byte, len(keys))
+	for i, key := range keys {
+		values[i] = []byte{}
+		v, _, readDataSize := this.GetByKey(path+hex.EncodeToString(key), newTypedElem(typeID))
+		gasMeter.Use(uint64(readDataSize), 0, 0) // Gas for reading the value
+		if v == nil {
+			continue
+		}
+
+		gasMeter.Use(0, 0, eucommon.GAS_ENCODE) // Gas for encoding the value
+		if encoded, err := encodeElem(typeID, v); err == nil {
+			flags[i], values[i] = true, encoded
+		}
+	}
+
+	encoded, err := EncodeBatch(flags, values)
+	gasMeter.Use(0, 0, eucommon.GAS_ENCODE) // Gas for encoding the results
+	return encoded, err == nil, gasMeter.TotalGasUsed
+}
+
+// Delete the elements by their keys.
+func (this *BaseHandlers) delBatch(caller evmcommon.Address, input []byte) ([]byte, bool, int64) {
+	gasMeter := eucommon.NewGasMeter()
+	path := this.pathBuilder.Key(caller) // Container path
+
+	arrays, err := DecodeBytesArrays(input, 1)
+	gasMeter.Use(0, 0, eucommon.GAS_DECODE) // Gas for decoding the keys
+	if err != nil {
+		return []byte{}, false, gasMeter.TotalGasUsed
+	}
+
+	keys := arrays[0]
+	flags := make([]bool, len(keys))
+	for i, key := range keys {
+		successful, writeDataSize := this.SetByKey(path+hex.EncodeToString(key), nil)
+		gasMeter.Use(0, writeDataSize, 0) // Gas for deleting the value
+		flags[i] = successful
+	}
+
+	encoded, err := EncodeBatch(flags)
+	gasMeter.Use(0, 0, eucommon.GAS_ENCODE) // Gas for encoding the flags
+	return encoded, err == nil, gasMeter.TotalGasUsed
+}
+
 func (this *BaseHandlers) keyToInd(caller evmcommon.Address, input []byte) ([]byte, bool, int64) {
 	gasMeter := eucommon.NewGasMeter()
 	path := this.pathBuilder.Key(caller) // BaseHandlers path
@@ -18,7 +18,14 @@
 package api
 
 import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math"
+	"math/big"
+	"slices"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/arcology-network/common-lib/exp/slice"
 	"github.com/arcology-network/common-lib/exp/softdeltaset"
@@ -27,6 +34,7 @@ import (
 	cache "github.com/arcology-network/storage-committer/storage/cache"
 	commutative "github.com/arcology-network/storage-committer/type/commutative"
 	noncommutative "github.com/arcology-network/storage-committer/type/noncommutative"
+	evmcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/holiman/uint256"
 )
 
@@ -62,6 +70,30 @@ func (this *BaseHandlers) ReadAll(path string) ([][]byte, []bool, int64) {
 	return entries, flags, int64(dataSize) + slice.Sum[int64, int64](dataSizes)
 }
 
+// Read the keys and the values of the elements in [start, start + count), the range is clamped to the
+// non-nil elements in the container. All the reads go through the write cache, so they are recorded.
+func (this *BaseHandlers) ReadRange(path string, start, count uint64) ([][]byte, [][]byte, bool, int64) {
+	length, successful, dataSize := this.NonNilLength(path)
+	if !successful || start >= length {
+		return [][]byte{}, [][]byte{}, successful, dataSize
+	}
+	count = min(count, length-start)
+
+	keys, values := make([][]byte, 0, count), make([][]byte, 0, count)
+	for i := start; i < start+count; i++ {
+		key, keySize := this.KeyAt(path, i)
+		value, ok, valueSize := this.GetByIndex(path, i)
+		dataSize += int64(keySize) + valueSize
+		if len(key) == 0 || !ok {
+			return keys, values, false, dataSize
+		}
+
+		rawKey, _ := hex.DecodeString(key)
+		keys, values = append(keys, rawKey), append(values, value)
+	}
+	return keys, values, true, dataSize
+}
+
 // Get the element by its key
 func (this *BaseHandlers) GetByKey(path string, T any) (any, bool, int64) {
 	value, _, dataSize := this.api.WriteCache().(*cache.WriteCache).Read(this.api.GetEU().(interface{ ID() uint64 }).ID(), path, T)
@@ -73,26 +105,16 @@ func (this *BaseHandlers) GetByIndex(path string, idx uint64) ([]byte, bool, int
 	keyidx := strings.LastIndex(path, "/")
 	elemTypeID := this.pathBuilder.PathElemTypeIDs(path[:keyidx] + "/") // Get the type of the container
 
-	var typedV any
-	switch elemTypeID {
-	case commutative.UINT256: // Commutative container
-		typedV = new(commutative.U256)
-	case noncommutative.BYTES: // Noncommutative container
-		typedV = new(noncommutative.Bytes)
-	case noncommutative.INT64:
-		typedV = new(noncommutative.Int64)
+	typedV := newTypedElem(elemTypeID)
+	if typedV == nil {
+		return []byte{}, false, int64(eucommon.DATA_MIN_READ_SIZE) // Unsupported element type
 	}
 
 	value, dataSize, err := this.api.WriteCache().(*cache.WriteCache).ReadAt(
 		this.api.GetEU().(interface{ ID() uint64 }).ID(), path, idx, typedV)
 
 	if err == nil && value != nil {
-		encoder := func(v any) ([]byte, bool, error) {
-			b, ok := v.([]byte)
-			return b, ok, nil
-		}
-
-		if encoded, err := abi.Encode(value, encoder); err == nil {
+		if encoded, err := encodeElem(elemTypeID, value); err == nil {
 			return encoded, true, int64(dataSize)
 		}
 	}
@@ -150,6 +172,15 @@ func (this *BaseHandlers) ResetByKey(path string, key string) ([]byte, bool, int
 
 	case noncommutative.INT64:
 		typedV = noncommutative.NewInt64(0) // Non-commutative container by default
+
+	case ADDRESS, BOOL, UINT64:
+		typedV = noncommutative.NewBytes([]byte{}) // Read as zero words
+
+	case noncommutative.STRING:
+		typedV = noncommutative.NewString("")
+
+	default:
+		return []byte{}, false, int64(readDataSize) // Unsupported element type
 	}
 
 	// Bytes by default
@@ -165,3 +196,95 @@ func (this *BaseHandlers) ExtractAt(path string, idx uint64) ([]byte, bool, int6
 	setSuccessful, writeDataSize := this.SetByIndex(path, idx, nil)
 	return funCall, getSuccessful && setSuccessful, readDataSize + writeDataSize
 }
+
+// Create an empty element of the container type, for the type info needed by the reads. It returns nil for the
+// unsupported types.
+func newTypedElem(typeID uint8) any {
+	switch typeID {
+	case commutative.UINT256: // Commutative container
+		return new(commutative.U256)
+	case noncommutative.BYTES, ADDRESS, BOOL, UINT64: // Noncommutative container
+		return new(noncommutative.Bytes)
+	case noncommutative.INT64:
+		return new(noncommutative.Int64)
+	case noncommutative.STRING:
+		return new(noncommutative.String)
+	}
+	return nil
+}
+
+// Convert the raw input value to an element of the container type. The U256 elements take signed deltas, the
+// address, bool and uint64 elements take ABI encoded words and the string elements take ABI encoded strings.
+func newElem(typeID uint8, valueBytes []byte) (any, error) {
+	switch typeID {
+	case commutative.UINT256: // Commutative container
+		// Decode the input delta value, could be negative or positive.
+		v, err := abi.DecodeInt256(valueBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		// Get the bytes from the delta bytes and Create a new delta value for the element
+		delta := new(uint256.Int).SetBytes(new(big.Int).Abs(v).Bytes())
+		return commutative.NewU256Delta(delta, v.Sign() >= 0), nil
+
+	case noncommutative.INT64:
+		bytes := make([]byte, 8)
+		copy(bytes, valueBytes) // valueBytes could be less than 8 bytes, so we need to copy it to the bytes array
+		return noncommutative.NewInt64(int64(binary.LittleEndian.Uint64(bytes))), nil
+
+	case noncommutative.BYTES:
+		return noncommutative.NewBytes(valueBytes), nil // Non-commutative container by default
+
+	case ADDRESS:
+		return decodeWord(valueBytes, 20)
+
+	case BOOL:
+		if len(valueBytes) == 32 && valueBytes[31] > 1 {
+			return nil, errors.New("Error: Invalid bool value")
+		}
+		return decodeWord(valueBytes, 1)
+
+	case UINT64:
+		return decodeWord(valueBytes, 8)
+
+	case noncommutative.STRING:
+		str, err := abi.DecodeTo(valueBytes, 0, []byte{}, 2, math.MaxInt) // Check the length against the data
+		if err != nil || !utf8.Valid(str) {
+			return nil, errors.New("Error: Invalid string value")
+		}
+		return noncommutative.NewString(string(str)), nil
+	}
+	return nil, errors.New("Error: Unsupported element type")
+}
+
+// Get the last size bytes of an ABI encoded word, the bytes before them have to be zeros.
+func decodeWord(valueBytes []byte, size int) (any, error) {
+	if len(valueBytes) != 32 || slices.ContainsFunc(valueBytes[:32-size], func(b byte) bool { return b != 0 }) {
+		return nil, errors.New("Error: Invalid word")
+	}
+	return noncommutative.NewBytes(slices.Clone(valueBytes[32-size:])), nil
+}
+
+// Encode an element read from the container of the type. The bytes and the int64 elements are returned as they are.
+func encodeElem(typeID uint8, value any) ([]byte, error) {
+	switch typeID {
+	case ADDRESS, BOOL, UINT64:
+		if bytes, ok := value.([]byte); ok {
+			return evmcommon.LeftPadBytes(bytes, 32), nil
+		}
+		return nil, errors.New("Error: Invalid word")
+
+	case noncommutative.STRING:
+		if str, ok := value.(string); ok {
+			return EncodeString(str)
+		}
+		return nil, errors.New("Error: Invalid string value")
+	}
+
+	// special decoder for byte array
+	return abi.Encode(value, func(v any) ([]byte, bool, error) {
+		b, ok := v.([]byte)
+		return b, ok, nil
+	})
+}
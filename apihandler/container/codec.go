@@ -0,0 +1,96 @@
+/*
+ *   Copyright (c) 2025 Arcology Network
+
+ *   This program is free software: you can redistribute it and/or modify
+ *   it under the terms of the GNU General Public License as published by
+ *   the Free Software Foundation, either version 3 of the License, or
+ *   (at your option) any later version.
+
+ *   This program is distributed in the hope that it will be useful,
+ *   but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *   GNU General Public License for more details.
+
+ *   You should have received a copy of the GNU General Public License
+ *   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package api
+
+import (
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// EncodeRange encodes the keys and the values of a range of elements as (bytes[], bytes[]).
+func EncodeRange(keys, values [][]byte) ([]byte, error) {
+	bytesArrayType, err := abi.NewType("bytes[]", "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	args := abi.Arguments{
+		{Type: bytesArrayType},
+		{Type: bytesArrayType},
+	}
+	return args.Pack(keys, values)
+}
+
+// DecodeBytesArrays decodes the input made of numArrays bytes[] arguments.
+func DecodeBytesArrays(input []byte, numArrays int) ([][][]byte, error) {
+	bytesArrayType, err := abi.NewType("bytes[]", "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	args := make(abi.Arguments, numArrays)
+	for i := range args {
+		args[i] = abi.Argument{Type: bytesArrayType}
+	}
+
+	decoded, err := args.Unpack(input)
+	if err != nil {
+		return nil, err
+	}
+
+	arrays := make([][][]byte, len(decoded))
+	for i, v := range decoded {
+		arrays[i] = v.([][]byte)
+	}
+	return arrays, nil
+}
+
+// EncodeBatch encodes the per element flags, and the values if there are any, as (bool[], bytes[]).
+func EncodeBatch(flags []bool, values ...[][]byte) ([]byte, error) {
+	boolArrayType, err := abi.NewType("bool[]", "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(values) == 0 {
+		return abi.Arguments{{Type: boolArrayType}}.Pack(flags)
+	}
+
+	bytesArrayType, err := abi.NewType("bytes[]", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	return abi.Arguments{{Type: boolArrayType}, {Type: bytesArrayType}}.Pack(flags, values[0])
+}
+
+// EncodeString encodes a string element the same way as abi.encode(string) in Solidity.
+func EncodeString(str string) ([]byte, error) {
+	stringType, err := abi.NewType("string", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	return abi.Arguments{{Type: stringType}}.Pack(str)
+}
+
+// EncodeEntry encodes the key and the value of an element as (bytes, bytes).
+func EncodeEntry(key, value []byte) ([]byte, error) {
+	bytesType, err := abi.NewType("bytes", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	return abi.Arguments{{Type: bytesType}, {Type: bytesType}}.Pack(key, value)
+}
@@ -0,0 +1,171 @@
+/*
+ *   Copyright (c) 2025 Arcology Network
+
+ *   This program is free software: you can redistribute it and/or modify
+ *   it under the terms of the GNU General Public License as published by
+ *   the Free Software Foundation, either version 3 of the License, or
+ *   (at your option) any later version.
+
+ *   This program is distributed in the hope that it will be useful,
+ *   but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *   GNU General Public License for more details.
+
+ *   You should have received a copy of the GNU General Public License
+ *   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package api
+
+import (
+	"encoding/hex"
+	"errors"
+	"math"
+	"strings"
+
+	abi "github.com/arcology-network/eu/abi"
+	eucommon "github.com/arcology-network/eu/common"
+	cache "github.com/arcology-network/storage-committer/storage/cache"
+	noncommutative "github.com/arcology-network/storage-committer/type/noncommutative"
+	evmcommon "github.com/ethereum/go-ethereum/common"
+)
+
+// A container with a comparator is a normal container with a comparator chosen at creation. It isn't a sorted
+// container, the elements are still stored in the insertion order and nothing is kept ordered on insert. So the
+// concurrent inserts of different keys only touch their own elements and don't conflict with each other.
+//
+// The ordered queries, lowerBound, upperBound, popMin and popMax, scan the whole container instead, like min() and
+// max() do. Each of them costs O(n) and pays the gas for every element read. All the elements end up in the read
+// set of the transaction as well, so the query can conflict with any concurrent write to the container, the inserts
+// of new keys included.
+
+// The path of the comparator of a container, next to the container path.
+func (this *BaseHandlers) comparatorPath(caller evmcommon.Address) string {
+	return strings.TrimSuffix(this.pathBuilder.Key(caller), "/") + "-order"
+}
+
+// Create a new container with a comparator for the ordered queries.
+func (this *BaseHandlers) newWithComparator(caller evmcommon.Address, input []byte) ([]byte, bool, int64) {
+	gasMeter := eucommon.NewGasMeter()
+	if len(input) < 96 {
+		return []byte{}, false, gasMeter.Use(0, 0, eucommon.GAS_DECODE).TotalGasUsed
+	}
+
+	elemTypeID, _ := abi.DecodeTo(input, 0, uint8(0), 1, 32)
+	mode, _ := abi.DecodeTo(input, 2, uint8(0), 1, 32)
+	gasMeter.Use(0, 0, eucommon.GAS_DECODE) // Gas for decoding the comparator
+	if _, err := comparator(mode, elemTypeID); err != nil {
+		return []byte{}, false, gasMeter.TotalGasUsed
+	}
+
+	ret, successful, gas := this.new(caller, input[:64]) // Create the container itself
+	gasMeter.Use(0, 0, gas)
+	if !successful {
+		return ret, false, gasMeter.TotalGasUsed
+	}
+
+	tx := this.api.GetEU().(interface{ ID() uint64 }).ID()
+	dataSize, err := this.api.WriteCache().(*cache.WriteCache).Write(tx, this.comparatorPath(caller), noncommutative.NewBytes([]byte{mode}))
+	gasMeter.Use(0, dataSize, eucommon.GAS_WRITE) // Gas for writing the comparator
+	return ret, err == nil, gasMeter.TotalGasUsed
+}
+
+// Read all the elements of a container along with its comparator, each element read is charged.
+func (this *BaseHandlers) readWithComparator(caller evmcommon.Address, gasMeter *eucommon.GasMeter) ([][]byte, [][]byte, func(lhv, rhv []byte) int, error) {
+	tx := this.api.GetEU().(interface{ ID() uint64 }).ID()
+	mode, _, dataSize := this.api.WriteCache().(*cache.WriteCache).Read(tx, this.comparatorPath(caller), new(noncommutative.Bytes))
+	gasMeter.Use(dataSize, 0, eucommon.GAS_READ) // Gas for reading the comparator
+	if bytes, ok := mode.([]byte); !ok || len(bytes) != 1 {
+		return nil, nil, nil, errors.New("Error: The container has no comparator")
+	}
+
+	compare, err := comparator(mode.([]byte)[0], this.pathBuilder.GetPathType(caller))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	keys, values, successful, readDataSize := this.ReadRange(this.pathBuilder.Key(caller), 0, math.MaxUint64)
+	gasMeter.Use(uint64(readDataSize), 0, eucommon.GAS_READ*int64(len(values))) // Gas for reading the elements
+	if !successful {
+		return nil, nil, nil, errors.New("Error: Failed to read the elements")
+	}
+	return keys, values, compare, nil
+}
+
+// Get the first element in the order not less than the value.
+func (this *BaseHandlers) lowerBound(caller evmcommon.Address, input []byte) ([]byte, bool, int64) {
+	return this.bound(caller, input, func(cmp int) bool { return cmp >= 0 })
+}
+
+// Get the first element in the order greater than the value.
+func (this *BaseHandlers) upperBound(caller evmcommon.Address, input []byte) ([]byte, bool, int64) {
+	return this.bound(caller, input, func(cmp int) bool { return cmp > 0 })
+}
+
+// Find the smallest element whose comparison result against the value meets the condition. The value is
+// encoded the same way as the elements returned by the container.
+func (this *BaseHandlers) bound(caller evmcommon.Address, input []byte, cond func(int) bool) ([]byte, bool, int64) {
+	gasMeter := eucommon.NewGasMeter()
+	target, err := abi.DecodeTo(input, 0, []byte{}, 2, math.MaxInt)
+	gasMeter.Use(0, 0, eucommon.GAS_DECODE) // Gas for decoding the value
+	if err != nil {
+		return []byte{}, false, gasMeter.TotalGasUsed
+	}
+
+	keys, values, compare, err := this.readWithComparator(caller, gasMeter)
+	if err != nil {
+		return []byte{}, false, gasMeter.TotalGasUsed
+	}
+
+	idx := -1
+	for i, v := range values {
+		if cond(compare(v, target)) && (idx < 0 || compare(v, values[idx]) < 0) {
+			idx = i
+		}
+	}
+
+	if idx < 0 {
+		return []byte{}, false, gasMeter.TotalGasUsed // No such element
+	}
+
+	encoded, err := EncodeEntry(keys[idx], values[idx])
+	gasMeter.Use(0, 0, eucommon.GAS_ENCODE) // Gas for encoding the result
+	return encoded, err == nil, gasMeter.TotalGasUsed
+}
+
+// Remove the smallest element in the order and return it.
+func (this *BaseHandlers) popMin(caller evmcommon.Address, _ []byte) ([]byte, bool, int64) {
+	return this.pop(caller, -1)
+}
+
+// Remove the largest element in the order and return it.
+func (this *BaseHandlers) popMax(caller evmcommon.Address, _ []byte) ([]byte, bool, int64) {
+	return this.pop(caller, 1)
+}
+
+// Remove the extreme element in the order, sign is -1 for the smallest and 1 for the largest. The first one
+// inserted wins the ties.
+func (this *BaseHandlers) pop(caller evmcommon.Address, sign int) ([]byte, bool, int64) {
+	gasMeter := eucommon.NewGasMeter()
+	keys, values, compare, err := this.readWithComparator(caller, gasMeter)
+	if err != nil || len(values) == 0 {
+		return []byte{}, false, gasMeter.TotalGasUsed
+	}
+
+	idx := 0
+	for i := 1; i < len(values); i++ {
+		if compare(values[i], values[idx])*sign > 0 {
+			idx = i
+		}
+	}
+
+	successful, writeDataSize := this.SetByKey(this.pathBuilder.Key(caller)+hex.EncodeToString(keys[idx]), nil)
+	gasMeter.Use(0, writeDataSize, 0) // Gas for deleting the element
+	if !successful {
+		return []byte{}, false, gasMeter.TotalGasUsed
+	}
+
+	encoded, err := EncodeEntry(keys[idx], values[idx])
+	gasMeter.Use(0, 0, eucommon.GAS_ENCODE) // Gas for encoding the result
+	return encoded, err == nil, gasMeter.TotalGasUsed
+}
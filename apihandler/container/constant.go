@@ -0,0 +1,55 @@
+/*
+ *   Copyright (c) 2025 Arcology Network
+
+ *   This program is free software: you can redistribute it and/or modify
+ *   it under the terms of the GNU General Public License as published by
+ *   the Free Software Foundation, either version 3 of the License, or
+ *   (at your option) any later version.
+
+ *   This program is distributed in the hope that it will be useful,
+ *   but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *   GNU General Public License for more details.
+
+ *   You should have received a copy of the GNU General Public License
+ *   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package api
+
+// The element types of the containers in addition to the ones from the storage committer. The values are stored
+// as noncommutative bytes in their compact forms and returned as ABI encoded words.
+const (
+	ADDRESS uint8 = 120
+	BOOL    uint8 = 121
+	UINT64  uint8 = 122
+)
+
+// The comparators to find the extreme elements in the containers.
+const (
+	CMP_UINT   uint8 = iota // Unsigned big-endian integers
+	CMP_INT256              // Signed int256 in two's complement
+	CMP_BYTES               // Lexicographic order of the raw bytes
+	CMP_STRING              // Lexicographic order of the strings
+)
+
+// The selectors of the functions in eval that don't change the container.
+var readOnlySelectors = map[[4]byte]bool{
+	{0xb4, 0xa1, 0x05, 0x4c}: true, // committedLength()
+	{0x86, 0x03, 0x9d, 0x78}: true, // fullLength()
+	{0x1f, 0x7b, 0x6d, 0x32}: true, // nonNilLength()
+	{0x91, 0x1f, 0x6f, 0xe0}: true, // keyToInd(bytes)
+	{0x06, 0xed, 0x32, 0x3c}: true, // indToKey(uint256)
+	{0x6b, 0x19, 0xdf, 0x9b}: true, // getByKey(bytes)
+	{0x2d, 0x88, 0x3a, 0x73}: true, // getByIndex(uint256)
+	{0xf5, 0x44, 0x1d, 0x17}: true, // getRange(uint256,uint256)
+	{0x98, 0x5c, 0x03, 0x5f}: true, // getRangeByKey(bytes,uint256)
+	{0x70, 0x60, 0x3c, 0xee}: true, // getBatch(bytes[])
+	{0xf8, 0x89, 0x79, 0x45}: true, // min()
+	{0x80, 0xbb, 0x99, 0xb8}: true, // min(uint8)
+	{0x6a, 0xc5, 0xdb, 0x19}: true, // max()
+	{0xc2, 0xe8, 0x91, 0x8e}: true, // max(uint8)
+	{0x28, 0x45, 0x8d, 0xf7}: true, // lowerBound(bytes)
+	{0x3e, 0x8c, 0xd2, 0x17}: true, // upperBound(bytes)
+	{0xf1, 0x06, 0x84, 0x54}: true, // pid()
+}
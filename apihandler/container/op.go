@@ -19,92 +19,103 @@ package api
 
 import (
 	"bytes"
+	"errors"
+	"math"
 	"math/big"
-
-	// "github.com/arcology-network/common-lib/exp/deltaset"
+	"strings"
 
 	"github.com/arcology-network/common-lib/exp/slice"
 
 	abi "github.com/arcology-network/eu/abi"
+	eucommon "github.com/arcology-network/eu/common"
+	noncommutative "github.com/arcology-network/storage-committer/type/noncommutative"
 	evmcommon "github.com/ethereum/go-ethereum/common"
+	evmmath "github.com/ethereum/go-ethereum/common/math"
 
 	"github.com/holiman/uint256"
 )
 
-// The function returns the minimum value in the container sorted by numerical order by
-// converting the byte array to a big integer and comparing the two values.
+// The function returns the minimum value in the container. The elements are compared as unsigned integers
+// if no comparator is given.
 func (this *BaseHandlers) min(caller evmcommon.Address, input []byte) ([]byte, bool, int64) {
-	path := this.pathBuilder.Key(caller)
-	entries, _, _ := this.ReadAll(path)
-
-	lhv, rhv := new(big.Int), new(big.Int)
-	idx, v := slice.Extreme(entries, func(lhvBytes, rhvBytes []byte) bool {
-		lhv.SetBytes(lhvBytes) // Convert the byte array to a big integer
-		rhv.SetBytes(rhvBytes)
-		return lhv.Cmp(rhv) < 0
-	})
-
-	// This leaves a read access for the minimum value in the container. It will be used for the conflict detection
-	if val, _, _ := this.GetByIndex(path, uint64(idx)); !bytes.Equal(v, val) {
-		panic("The value is not equal to the value in the container.")
-	}
-
-	idxBytes, _ := abi.Encode(uint256.NewInt(uint64(idx)))
-	return append(idxBytes, v...), true, 0
+	return this.extreme(caller, input, -1)
 }
 
-// The function max returns the maximum value in the container sorted by numerical order by
-// converting the byte array to a big integer and comparing the two values.
+// The function max returns the maximum value in the container. The elements are compared as unsigned integers
+// if no comparator is given.
 func (this *BaseHandlers) max(caller evmcommon.Address, input []byte) ([]byte, bool, int64) {
-	path := this.pathBuilder.Key(caller)
-	entries, _, _ := this.ReadAll(path)
-
-	lhv, rhv := new(big.Int), new(big.Int)
-	idx, v := slice.Extreme(entries, func(lhvBytes, rhvBytes []byte) bool {
-		lhv.SetBytes(lhvBytes) // Convert the byte array to a big integer
-		rhv.SetBytes(rhvBytes)
-		return lhv.Cmp(rhv) > 0
-	})
-
-	// This leaves a read access for the maxmium value in the container. It will be used for the conflict detection
-	if val, _, _ := this.GetByIndex(path, uint64(idx)); !bytes.Equal(v, val) {
-		panic("The value is not equal to the value in the container.")
-	}
-
-	idxBytes, _ := abi.Encode(uint256.NewInt(uint64(idx)))
-	return append(idxBytes, v...), true, 0
+	return this.extreme(caller, input, 1)
 }
 
-// func (this *BaseHandlers) minString(caller evmcommon.Address, input []byte) ([]byte, bool, int64) {
-// 	path := this.pathBuilder.Key(caller)
-// 	entries, _, _ := this.ReadAll(path)
+// Find the extreme element in the container, sign is -1 for the minimum and 1 for the maximum. The whole container
+// is read, so the gas is in proportion to the number of the elements scanned.
+func (this *BaseHandlers) extreme(caller evmcommon.Address, input []byte, sign int) ([]byte, bool, int64) {
+	gasMeter := eucommon.NewGasMeter()
+	path := this.pathBuilder.Key(caller)
 
-// 	idx, v := slice.Extreme(entries, func(lhv, rhv []byte) bool {
-// 		return string(lhv) < string(rhv)
-// 	})
+	mode := CMP_UINT
+	if len(input) >= 32 {
+		mode, _ = abi.DecodeTo(input, 0, uint8(0), 1, 32)
+		gasMeter.Use(0, 0, eucommon.GAS_DECODE) // Gas for decoding the comparator
+	}
 
-// 	// This leaves a read access for the maxmium string in the container. It will be used for the conflict detection
-// 	if val, _, _ := this.GetByIndex(path, uint64(idx)); !bytes.Equal(v, val) {
-// 		panic("The value is not equal to the value in the container.")
-// 	}
+	compare, err := comparator(mode, this.pathBuilder.GetPathType(caller))
+	gasMeter.Use(0, 0, eucommon.GAS_GET_RUNTIME_INFO)
+	if err != nil {
+		return []byte{}, false, gasMeter.TotalGasUsed
+	}
 
-// 	idxBytes, _ := abi.Encode(uint256.NewInt(uint64(idx)))
-// 	return append(idxBytes, v...), true, 0
-// }
+	entries, _, dataSize := this.ReadAll(path)
+	gasMeter.Use(uint64(dataSize), 0, eucommon.GAS_READ*int64(len(entries))) // Gas for reading all the elements
+	if len(entries) == 0 {
+		return []byte{}, false, gasMeter.TotalGasUsed
+	}
 
-// func (this *BaseHandlers) maxString(caller evmcommon.Address, input []byte) ([]byte, bool, int64) {
-// 	path := this.pathBuilder.Key(caller)
-// 	entries, _, _ := this.ReadAll(path)
+	idx, v := slice.Extreme(entries, func(lhv, rhv []byte) bool { return compare(lhv, rhv)*sign > 0 })
 
-// 	idx, v := slice.Extreme(entries, func(lhv, rhv []byte) bool {
-// 		return string(lhv) > string(rhv)
-// 	})
+	// This leaves a read access for the extreme value in the container. It will be used for the conflict detection
+	val, _, readDataSize := this.GetByIndex(path, uint64(idx))
+	gasMeter.Use(uint64(readDataSize), 0, eucommon.GAS_READ)
+	if !bytes.Equal(v, val) {
+		return []byte{}, false, gasMeter.TotalGasUsed // The value has changed since it was read
+	}
 
-// 	// This leaves a read access for the maxmium string in the container. It will be used for the conflict detection
-// 	if val, _, _ := this.GetByIndex(path, uint64(idx)); !bytes.Equal(v, val) {
-// 		panic("The value is not equal to the value in the container.")
-// 	}
+	idxBytes, _ := abi.Encode(uint256.NewInt(uint64(idx)))
+	gasMeter.Use(0, 0, eucommon.GAS_ENCODE) // Gas for encoding the result
+	return append(idxBytes, v...), true, gasMeter.TotalGasUsed
+}
 
-// 	idxBytes, _ := abi.Encode(uint256.NewInt(uint64(idx)))
-// 	return append(idxBytes, v...), true, 0
-// }
+// comparator returns the function comparing the encoded elements of a container in the mode.
+func comparator(mode uint8, elemTypeID uint8) (func(lhv, rhv []byte) int, error) {
+	switch mode {
+	case CMP_UINT:
+		lhv, rhv := new(big.Int), new(big.Int)
+		return func(lhvBytes, rhvBytes []byte) int {
+			return lhv.SetBytes(lhvBytes).Cmp(rhv.SetBytes(rhvBytes)) // Convert the byte array to a big integer
+		}, nil
+
+	case CMP_INT256:
+		return func(lhvBytes, rhvBytes []byte) int {
+			if len(lhvBytes) > 32 || len(rhvBytes) > 32 {
+				return bytes.Compare(lhvBytes, rhvBytes) // Not an int256, keep the order stable
+			}
+			return evmmath.S256(new(big.Int).SetBytes(lhvBytes)).Cmp(evmmath.S256(new(big.Int).SetBytes(rhvBytes)))
+		}, nil
+
+	case CMP_BYTES:
+		return bytes.Compare, nil
+
+	case CMP_STRING:
+		if elemTypeID != noncommutative.STRING {
+			return func(lhv, rhv []byte) int { return strings.Compare(string(lhv), string(rhv)) }, nil
+		}
+
+		// The string elements are ABI encoded, compare the strings inside.
+		return func(lhv, rhv []byte) int {
+			lhvStr, _ := abi.DecodeTo(lhv, 0, []byte{}, 2, math.MaxInt)
+			rhvStr, _ := abi.DecodeTo(rhv, 0, []byte{}, 2, math.MaxInt)
+			return strings.Compare(string(lhvStr), string(rhvStr))
+		}, nil
+	}
+	return nil, errors.New("Error: Unknown comparator")
+}
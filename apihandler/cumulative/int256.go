@@ -17,163 +17,212 @@
 
 package api
 
-// import (
-// 	"encoding/hex"
-
-// 	"github.com/arcology-network/common-lib/codec"
-// 	"github.com/arcology-network/storage-committer"
-// 	"github.com/holiman/uint256"
-
-// 	"github.com/arcology-network/storage-committer/type/commutative"
-// 	evmcommon "github.com/ethereum/go-ethereum/common"
-// 	abi "github.com/arcology-network/eu/abi"
-// 	"github.com/arcology-network/eu/common"
-// 	eucommon "github.com/arcology-network/eu/common"
-
-//
-// )
-
-// // APIs under the concurrency namespace
-// type Int256CumulativeHandler struct {
-// 	api       intf.EthApiRouter,
-// 	connector *BuiltinPathMaker
-// }
-
-// func NewInt256CumulativeHandler(api intf.EthApiRouter) *Int256CumulativeHandler {
-// 	return &Int256CumulativeHandler{
-// 		api:       api,
-// 		connector: NewBuiltinPathMaker("/container", api, api.WriteCache()),
-// 	}
-// }
-
-// func (this *Int256CumulativeHandler) Address() [20]byte {
-// 	return common.CUMULATIVE_I256_HANDLER
-// }
-
-// func (this *Int256CumulativeHandler) Call(caller, callee [20]byte, input []byte, origin [20]byte, nonce uint64) ([]byte, bool, int64) {
-// 	signature := [4]byte{}
-// 	copy(signature[:], input)
-
-// 	switch signature {
-// 	case [4]byte{0x90, 0x54, 0xce, 0x5f}:
-// 		return this.new(caller, input[4:])
-
-// 	case [4]byte{0x6d, 0x4c, 0xe6, 0x3c}:
-// 		return this.get(caller, input[4:])
-
-// 	case [4]byte{0xa4, 0xc6, 0xa7, 0x68}:
-// 		return this.add(caller, input[4:])
-
-// 	case [4]byte{0xc8, 0xda, 0xaa, 0xab}:
-// 		return this.sub(caller, input[4:])
-// 	}
-// 	return this.Unknow(caller, input)
-// }
-
-// func (this *Int256CumulativeHandler) Unknow(caller evmcommon.Address, input []byte) ([]byte, bool, int64) {
-// 	this.api.AddLog("Unhandled function call in cumulative handler router", hex.EncodeToString(input))
-// 	return []byte{}, false, 0
-// }
-
-// func (this *Int256CumulativeHandler) new(caller evmcommon.Address, input []byte) ([]byte, bool, int64) {
-// 	id := this.api.UUID()
-// 	if !this.connector.New(types.Address(codec.Bytes20(caller).Hex()), hex.EncodeToString(id)) { // A new container
-// 		return []byte{}, false, 0
-// 	}
-
-// 	path := this.connector.Key(types.Address(codec.Bytes20(caller).Hex()), hex.EncodeToString(id))
-// 	key := path + string(this.api.ElementUID()) // Element ID
-
-// 	// val, valErr := abi.Decode(input, 0, &uint256.Int{}, 1, 32)
-// 	min, minErr := abi.Decode(input, 0, &uint256.Int{}, 1, 32)
-// 	max, maxErr := abi.Decode(input, 1, &uint256.Int{}, 1, 32)
-// 	if minErr != nil || maxErr != nil {
-// 		return []byte{}, false, 0
-// 	}
-
-// 	newU256 := commutative.NewU256(min.(*uint256.Int), max.(*uint256.Int))
-// 	if _, err := this.api.WriteCache().(*cache.WriteCache).Write(uint32(this.api.StdMessage().(*execution.StandardMessage).ID), key, newU256, true); err != nil {
-// 		return []byte{}, false, 0
-// 	}
-// 	return id, true, 0
-// }
-
-// func (this *Int256CumulativeHandler) get(caller evmcommon.Address, input []byte) ([]byte, bool, int64) {
-// 	path :=  this.connector.Key(caller)// Build container path
-// 	if len(path) == 0 || err != nil {
-// 		return []byte{}, false, 0
-// 	}
-
-// 	if value, _, err := this.api.WriteCache().(*cache.WriteCache).ReadAt(uint32(this.api.StdMessage().(*execution.StandardMessage).ID), path, 0); value == nil || err != nil {
-// 		return []byte{}, false, 0
-// 	} else {
-
-// 		updated := value.(*uint256.Int)
-// 		if encoded, err := abi.Encode(updated); err == nil { // Encode the result
-// 			return encoded, true, 0
-// 		}
-// 	}
-// 	return []byte{}, false, 0
-// }
-
-// func (this *Int256CumulativeHandler) add(caller evmcommon.Address, input []byte) ([]byte, bool, int64) {
-// 	path :=  this.connector.Key(caller)// Build container path
-// 	if len(path) == 0 || err != nil {
-// 		return []byte{}, false, 0
-// 	}
-
-// 	delta, err := abi.Decode(input, 1, &uint256.Int{}, 1, 32)
-// 	if err != nil {
-// 		return []byte{}, false, 0
-// 	}
-
-// 	value := commutative.NewU256Delta(delta.(*uint256.Int), true)
-// 	_, err = this.api.WriteCache().(*cache.WriteCache).WriteAt(uint32(this.api.StdMessage().(*execution.StandardMessage).ID), path, 0, value, true)
-// 	return []byte{}, err == nil, 0
-// }
-
-// func (this *Int256CumulativeHandler) sub(caller evmcommon.Address, input []byte) ([]byte, bool, int64) {
-// 	path :=  this.connector.Key(caller)// Build container path
-// 	if len(path) == 0 || err != nil {
-// 		return []byte{}, false, 0
-// 	}
-
-// 	delta, err := abi.Decode(input, 1, &uint256.Int{}, 1, 32)
-// 	if err != nil {
-// 		return []byte{}, false, 0
-// 	}
-
-// 	value := commutative.NewU256Delta(delta.(*uint256.Int), false)
-// 	_, err = this.api.WriteCache().(*cache.WriteCache).WriteAt(uint32(this.api.StdMessage().(*execution.StandardMessage).ID), path, 0, value, true)
-// 	return []byte{}, err == nil, 0
-// }
-
-// func (this *Int256CumulativeHandler) set(caller evmcommon.Address, input []byte) ([]byte, bool, int64) {
-// 	path :=  this.connector.Key(caller)// Build container path
-// 	if len(path) == 0 || err != nil {
-// 		return []byte{}, false, 0
-// 	}
-
-// 	delta, err := abi.DecodeTo(input, 1, &uint256.Int{}, 1, 32)
-// 	if err != nil {
-// 		return []byte{}, false, 0
-// 	}
-
-// 	sign, err := abi.DecodeTo(input, 1, bool(true), 1, 32)
-// 	if err != nil {
-// 		return []byte{}, false, 0
-// 	}
-
-// 	value := commutative.NewU256Delta(delta, sign)
-// 	_, err = this.api.WriteCache().(*cache.WriteCache).WriteAt(uint32(this.api.StdMessage().(*execution.StandardMessage).ID), path, 0, value, true)
-// 	return []byte{}, err == nil, 0
-// }
-
-// // Build the container path
-// func (this *Int256CumulativeHandler) buildPath(caller evmcommon.Address, input []byte) (string, error) {
-// 	id, err := abi.Decode(input, 0, []byte{}, 2, 32) // max 32 bytes
-// 	if err != nil {
-// 		return "", nil
-// 	} // container ID
-// 	return this.connector.Key(types.Address(codec.Bytes20(caller).Hex()), hex.EncodeToString(id.([]byte))), nil // unique ID
-// }
+import (
+	"encoding/hex"
+
+	"github.com/arcology-network/common-lib/codec"
+	"github.com/arcology-network/common-lib/types"
+	"github.com/holiman/uint256"
+
+	abi "github.com/arcology-network/eu/abi"
+	"github.com/arcology-network/eu/common"
+	cache "github.com/arcology-network/storage-committer/storage/cache"
+	commutative "github.com/arcology-network/storage-committer/type/commutative"
+	evmcommon "github.com/ethereum/go-ethereum/common"
+
+	path "github.com/arcology-network/eu/eth"
+	intf "github.com/arcology-network/eu/interface"
+)
+
+// The signed values are stored in U256s with their sign bits flipped, which maps [-2^255, 2^255) to [0, 2^256)
+// in the same order. So the bounds and the commutative deltas of the U256 work for the signed values as well.
+var i256SignBit = new(uint256.Int).Lsh(uint256.NewInt(1), 255)
+
+// Convert between the two's complement of an int256 and its U256 representation, the same operation both ways.
+func flipSignBit(v *uint256.Int) *uint256.Int { return new(uint256.Int).Xor(v, i256SignBit) }
+
+// The variables share the container path with the U256 ones, the prefix keeps them apart.
+const i256KeyPrefix = "i256-"
+
+// Int256CumHandler handles the Int256Cumulative APIs, a signed version of the U256CumHandler.
+type Int256CumHandler struct {
+	api       intf.EthApiRouter
+	connector *path.PathBuilder
+	key       string
+}
+
+func NewInt256CumulativeHandler(api intf.EthApiRouter) *Int256CumHandler {
+	k := [20]byte{}
+	return &Int256CumHandler{
+		api:       api,
+		connector: path.NewPathBuilder("/storage/container", api),
+		key:       i256KeyPrefix + hex.EncodeToString(k[:]),
+	}
+}
+
+func (this *Int256CumHandler) Address() [20]byte {
+	return common.CUMULATIVE_I256_HANDLER
+}
+
+func (this *Int256CumHandler) Call(caller, callee [20]byte, input []byte, origin [20]byte, nonce uint64, isReadOnly bool) ([]byte, bool, int64) {
+	signature := codec.Bytes4{}.FromBytes(input)
+
+	if isReadOnly {
+		switch signature {
+		case [4]byte{0x59, 0xe0, 0x2d, 0xd7}: // peek()
+			return this.peek(caller, input[4:])
+
+		case [4]byte{0x6d, 0x4c, 0xe6, 0x3c}: // get()
+			return this.get(caller, input[4:])
+
+		case [4]byte{0xf8, 0x89, 0x79, 0x45}: // min()
+			return this.min(caller, input[4:])
+
+		case [4]byte{0x6a, 0xc5, 0xdb, 0x19}: // max()
+			return this.max(caller, input[4:])
+		}
+	} else {
+		switch signature {
+		case [4]byte{0xfa, 0xe4, 0x91, 0xe7}: // new(int256,int256)
+			return this.new(caller, input[4:])
+
+		case [4]byte{0x87, 0xdb, 0x03, 0xb7}: // add(int256)
+			return this.add(caller, input[4:])
+
+		case [4]byte{0xfa, 0x3b, 0xd6, 0xc5}: // sub(int256)
+			return this.sub(caller, input[4:])
+		}
+	}
+	return []byte{}, false, common.GAS_CALL_UNKNOW
+}
+
+func (this *Int256CumHandler) new(caller evmcommon.Address, input []byte) ([]byte, bool, int64) {
+	gasMeter := common.NewGasMeter()
+
+	txIndex := this.api.GetEU().(interface{ ID() uint64 }).ID()
+	if ok, _ := this.connector.CreateNewAccount(txIndex, types.Address(codec.Bytes20(caller).Hex())); !ok { // A new container
+		return []byte{}, false, gasMeter.TotalGasUsed
+	}
+
+	min, minErr := abi.Decode(input, 0, &uint256.Int{}, 1, 32)
+	max, maxErr := abi.Decode(input, 1, &uint256.Int{}, 1, 32)
+	gasMeter.Use(0, 0, common.GAS_DECODE*2) // Gas for decoding the bounds
+	if minErr != nil || maxErr != nil {
+		return []byte{}, false, gasMeter.TotalGasUsed
+	}
+
+	lower, upper := flipSignBit(min.(*uint256.Int)), flipSignBit(max.(*uint256.Int))
+	if lower.Gt(upper) {
+		return []byte{}, false, gasMeter.TotalGasUsed
+	}
+
+	// Starts from 0, or from the lower bound if 0 is out of the bounds.
+	initv := flipSignBit(uint256.NewInt(0))
+	if initv.Lt(lower) || initv.Gt(upper) {
+		initv = lower
+	}
+
+	keyPath := this.connector.Key(caller) + string(this.key) // Element ID
+	newI256 := commutative.NewBoundedU256(lower, upper)
+	newI256.(*commutative.U256).SetValue(*initv)
+	writeDataSize, err := this.api.WriteCache().(*cache.WriteCache).Write(txIndex, keyPath, newI256)
+	gasMeter.Use(0, writeDataSize, common.GAS_WRITE) // Gas for creating the variable
+	return []byte{}, err == nil, gasMeter.TotalGasUsed
+}
+
+func (this *Int256CumHandler) get(caller evmcommon.Address, input []byte) ([]byte, bool, int64) {
+	gasMeter := common.NewGasMeter()
+
+	keyPath := this.connector.Key(caller) + string(this.key) // Element ID
+	value, _, readDataSize := this.api.WriteCache().(*cache.WriteCache).Read(this.api.GetEU().(interface{ ID() uint64 }).ID(), keyPath, new(commutative.U256))
+	gasMeter.Use(readDataSize, 0, common.GAS_READ) // Gas for reading the variable
+	if value == nil {
+		return []byte{}, false, gasMeter.TotalGasUsed
+	}
+
+	updated := value.(uint256.Int)
+	gasMeter.Use(0, 0, common.GAS_ENCODE) // Gas for encoding the result
+	encoded, err := abi.Encode(flipSignBit(&updated))
+	return encoded, err == nil, gasMeter.TotalGasUsed
+}
+
+// Peek reads the initial value from the WriteCache, see U256CumHandler.peek().
+func (this *Int256CumHandler) peek(caller evmcommon.Address, input []byte) ([]byte, bool, int64) {
+	gasMeter := common.NewGasMeter()
+
+	keyPath := this.connector.Key(caller) + string(this.key) // Element ID
+	value, readDataSize := this.api.WriteCache().(*cache.WriteCache).PeekCommitted(keyPath, new(commutative.U256))
+	gasMeter.Use(uint64(readDataSize), 0, common.GAS_READ) // Gas for reading the committed value
+	if value == nil {
+		return []byte{}, false, gasMeter.TotalGasUsed
+	}
+
+	initv := value.(*commutative.U256).Value().(uint256.Int)
+	gasMeter.Use(0, 0, common.GAS_ENCODE) // Gas for encoding the result
+	encoded, err := abi.Encode(flipSignBit(&initv))
+	return encoded, err == nil, gasMeter.TotalGasUsed
+}
+
+// Add adds a signed delta to the variable's delta.
+func (this *Int256CumHandler) add(caller evmcommon.Address, input []byte) ([]byte, bool, int64) {
+	return this.set(caller, input, true)
+}
+
+// Sub subtracts a signed delta from the variable's delta.
+func (this *Int256CumHandler) sub(caller evmcommon.Address, input []byte) ([]byte, bool, int64) {
+	return this.set(caller, input, false)
+}
+
+func (this *Int256CumHandler) set(caller evmcommon.Address, input []byte, isPositive bool) ([]byte, bool, int64) {
+	gasMeter := common.NewGasMeter()
+
+	delta, err := abi.Decode(input, 0, &uint256.Int{}, 1, 32)
+	gasMeter.Use(0, 0, common.GAS_DECODE) // Gas for decoding the delta
+	if err != nil {
+		return []byte{}, false, gasMeter.TotalGasUsed
+	}
+
+	// Split the two's complement into the sign and the magnitude. The magnitude of -2^255 is still 2^255.
+	magnitude := delta.(*uint256.Int)
+	if magnitude.Sign() < 0 {
+		magnitude, isPositive = new(uint256.Int).Neg(magnitude), !isPositive
+	}
+	value := commutative.NewU256Delta(magnitude, isPositive)
+
+	txIndex := this.api.GetEU().(interface{ ID() uint64 }).ID()
+	keyPath := this.connector.Key(caller) + string(this.key) // Element ID
+	writeDataSize, err := this.api.WriteCache().(*cache.WriteCache).Write(txIndex, keyPath, value)
+	gasMeter.Use(0, writeDataSize, common.GAS_DELTA_WRITE) // Gas for writing the delta
+	return []byte{}, err == nil, gasMeter.TotalGasUsed
+}
+
+func (this *Int256CumHandler) min(caller evmcommon.Address, input []byte) ([]byte, bool, int64) {
+	return this.limit(caller, true)
+}
+
+func (this *Int256CumHandler) max(caller evmcommon.Address, input []byte) ([]byte, bool, int64) {
+	return this.limit(caller, false)
+}
+
+// Limit gets the lower or the upper bound of the variable, they are read only.
+func (this *Int256CumHandler) limit(caller evmcommon.Address, isMin bool) ([]byte, bool, int64) {
+	gasMeter := common.NewGasMeter()
+
+	txIndex := this.api.GetEU().(interface{ ID() uint64 }).ID()
+	keyPath := this.connector.Key(caller) + string(this.key) // Element ID
+	value, _, _ := this.api.WriteCache().(*cache.WriteCache).FindForRead(txIndex, keyPath, new(commutative.U256), nil)
+	gasMeter.Use(common.DATA_MIN_READ_SIZE, 0, common.GAS_READ) // Gas for reading the bounds
+	if value == nil {
+		return []byte{}, false, gasMeter.TotalGasUsed
+	}
+
+	limit, max := value.(*commutative.U256).Limits()
+	if !isMin {
+		limit = max
+	}
+
+	limitv := limit.(uint256.Int)
+	gasMeter.Use(0, 0, common.GAS_ENCODE) // Gas for encoding the result
+	encoded, err := abi.Encode(flipSignBit(&limitv))
+	return encoded, err == nil, gasMeter.TotalGasUsed
+}
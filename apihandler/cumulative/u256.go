@@ -35,11 +35,12 @@ import (
 	intf "github.com/arcology-network/eu/interface"
 )
 
-// U256CumulativeHandlers handles the U256Cumulative APIs that can be called by concurrent API called.
+// U256CumulativeHandlers handles the U256Cumulative APIs that can be called by concurrent API called. A contract
+// can have multiple variables identified by their IDs, the functions without the IDs work on the default one.
 type U256CumHandler struct {
 	api       intf.EthApiRouter
 	connector *path.PathBuilder
-	key       string
+	key       string // ID of the default variable
 }
 
 func NewU256CumulativeHandler(api intf.EthApiRouter) *U256CumHandler {
@@ -64,150 +65,174 @@ func (this *U256CumHandler) Call(caller, callee [20]byte, input []byte, origin [
 	if isReadOnly {
 		switch signature {
 		case [4]byte{0x59, 0xe0, 0x2d, 0xd7}: // 59 e0 2d d7
-			return this.peek(caller, input[4:])
+			return this.peek(caller, this.key, input[4:])
 
 		case [4]byte{0x6d, 0x4c, 0xe6, 0x3c}:
-			return this.get(caller, input[4:])
+			return this.get(caller, this.key, input[4:])
 
 		case [4]byte{0xf8, 0x89, 0x79, 0x45}: // f8 89 79 45
-			return this.min(caller, input[4:]) // Get the lower bound of the variable
+			return this.min(caller, this.key, input[4:]) // Get the lower bound of the variable
 
 		case [4]byte{0x6a, 0xc5, 0xdb, 0x19}:
-			return this.max(caller, input[4:]) // Get the upper bound of the variable
+			return this.max(caller, this.key, input[4:]) // Get the upper bound of the variable
+
+		// The named variables, the variable ID is the first argument.
+		case [4]byte{0x7f, 0x86, 0xd1, 0xeb}: // peek(bytes32)
+			return this.named(caller, input[4:], this.peek)
+
+		case [4]byte{0x8e, 0xaa, 0x6a, 0xc0}: // get(bytes32)
+			return this.named(caller, input[4:], this.get)
+
+		case [4]byte{0x3d, 0x98, 0xc9, 0x59}: // min(bytes32)
+			return this.named(caller, input[4:], this.min)
+
+		case [4]byte{0xa1, 0x62, 0x7f, 0xa9}: // max(bytes32)
+			return this.named(caller, input[4:], this.max)
 		}
 	} else {
 		switch signature {
 		case [4]byte{0x1c, 0x64, 0x49, 0x9c}:
-			return this.new(caller, input[4:])
+			return this.new(caller, this.key, input[4:])
 
 		case [4]byte{0x10, 0x03, 0xe2, 0xd2}: // 10 03 e2 d2
-			return this.add(caller, input[4:])
+			return this.add(caller, this.key, input[4:])
 
 		case [4]byte{0x27, 0xee, 0x58, 0xa6}:
-			return this.sub(caller, input[4:]) //27 ee 58 a6
+			return this.sub(caller, this.key, input[4:]) //27 ee 58 a6
+
+		case [4]byte{0xd0, 0xc2, 0xdc, 0x66}: // new(bytes32,uint256,uint256)
+			return this.named(caller, input[4:], this.new)
+
+		case [4]byte{0x5f, 0xfa, 0x4b, 0xce}: // add(bytes32,uint256)
+			return this.named(caller, input[4:], this.add)
+
+		case [4]byte{0x2e, 0xe9, 0xd8, 0xc2}: // sub(bytes32,uint256)
+			return this.named(caller, input[4:], this.sub)
 		}
 	}
-	return []byte{}, false, 0
+	return []byte{}, false, common.GAS_CALL_UNKNOW
 }
 
-func (this *U256CumHandler) new(caller evmcommon.Address, input []byte) ([]byte, bool, int64) {
+// Named calls the function on the variable whose ID is the first argument, the rest of the arguments are passed on.
+// The IDs are 32 bytes long, so the named variables never collide with the default one.
+func (this *U256CumHandler) named(caller evmcommon.Address, input []byte, f func(evmcommon.Address, string, []byte) ([]byte, bool, int64)) ([]byte, bool, int64) {
+	if len(input) < 32 {
+		return []byte{}, false, common.GAS_DECODE
+	}
+	return f(caller, hex.EncodeToString(input[:32]), input[32:])
+}
+
+func (this *U256CumHandler) new(caller evmcommon.Address, key string, input []byte) ([]byte, bool, int64) {
+	gasMeter := common.NewGasMeter()
+
 	txIndex := this.api.GetEU().(interface{ ID() uint64 }).ID()
 	if ok, _ := this.connector.CreateNewAccount(txIndex, types.Address(codec.Bytes20(caller).Hex())); !ok { // A new container
-		return []byte{}, false, 0
+		return []byte{}, false, gasMeter.TotalGasUsed
 	}
 
 	min, minErr := abi.Decode(input, 0, &uint256.Int{}, 1, 32)
 	max, maxErr := abi.Decode(input, 1, &uint256.Int{}, 1, 32)
+	gasMeter.Use(0, 0, common.GAS_DECODE*2) // Gas for decoding the bounds
 	if minErr != nil || maxErr != nil {
-		return []byte{}, false, 0
+		return []byte{}, false, gasMeter.TotalGasUsed
 	}
 
-	keyPath := this.connector.Key(caller) + string(this.key) // Element ID
+	keyPath := this.connector.Key(caller) + key // Variable ID
 	newU256 := commutative.NewBoundedU256(min.(*uint256.Int), max.(*uint256.Int))
-	if _, err := this.api.WriteCache().(*cache.WriteCache).Write(txIndex, keyPath, newU256); err != nil {
-		return []byte{}, false, 0
-	}
-	return []byte{}, true, 0
+	writeDataSize, err := this.api.WriteCache().(*cache.WriteCache).Write(txIndex, keyPath, newU256)
+	gasMeter.Use(0, writeDataSize, common.GAS_WRITE) // Gas for creating the variable
+	return []byte{}, err == nil, gasMeter.TotalGasUsed
 }
 
-func (this *U256CumHandler) get(caller evmcommon.Address, input []byte) ([]byte, bool, int64) {
-	path := this.connector.Key(caller) // Build container path
-	if len(path) == 0 {
-		return []byte{}, false, 0
-	}
+func (this *U256CumHandler) get(caller evmcommon.Address, key string, input []byte) ([]byte, bool, int64) {
+	gasMeter := common.NewGasMeter()
 
-	keyPath := path + string(this.key) // Element ID
-	if value, _, _ := this.api.WriteCache().(*cache.WriteCache).Read(this.api.GetEU().(interface{ ID() uint64 }).ID(), keyPath, new(commutative.U256)); value == nil {
-		return []byte{}, false, 0
-	} else {
-		updated := value.(uint256.Int)
-		if encoded, err := abi.Encode(updated); err == nil { // Encode the result
-			return encoded, true, 0
-		}
+	keyPath := this.connector.Key(caller) + key // Variable ID
+	value, _, readDataSize := this.api.WriteCache().(*cache.WriteCache).Read(this.api.GetEU().(interface{ ID() uint64 }).ID(), keyPath, new(commutative.U256))
+	gasMeter.Use(readDataSize, 0, common.GAS_READ) // Gas for reading the variable
+	if value == nil {
+		return []byte{}, false, gasMeter.TotalGasUsed
 	}
-	return []byte{}, false, 0
+
+	updated := value.(uint256.Int)
+	gasMeter.Use(0, 0, common.GAS_ENCODE) // Gas for encoding the result
+	encoded, err := abi.Encode(updated)
+	return encoded, err == nil, gasMeter.TotalGasUsed
 }
 
 // Peek reads the initial value from the WriteCache. It assumes that the initial value
 // is always in the cache by the time it is called.
-func (this *U256CumHandler) peek(caller evmcommon.Address, input []byte) ([]byte, bool, int64) {
-	path := this.connector.Key(caller) // Build container path
-	if len(path) == 0 {
-		return []byte{}, false, 0
+func (this *U256CumHandler) peek(caller evmcommon.Address, key string, input []byte) ([]byte, bool, int64) {
+	gasMeter := common.NewGasMeter()
+
+	keyPath := this.connector.Key(caller) + key // Variable ID
+	value, readDataSize := this.api.WriteCache().(*cache.WriteCache).PeekCommitted(keyPath, new(commutative.U256))
+	gasMeter.Use(uint64(readDataSize), 0, common.GAS_READ) // Gas for reading the committed value
+	if value == nil {
+		return []byte{}, false, gasMeter.TotalGasUsed
 	}
 
-	keyPath := path + string(this.key) // Element ID
-	if value, _ := this.api.WriteCache().(*cache.WriteCache).PeekCommitted(keyPath, new(commutative.U256)); value != nil {
-		initv := value.(*commutative.U256).Value().(uint256.Int)
-		if encoded, err := abi.Encode((*uint256.Int)(&initv)); err == nil { // Encode the result
-			return encoded, true, 0
-		}
-	}
-	return []byte{}, false, 0
+	initv := value.(*commutative.U256).Value().(uint256.Int)
+	gasMeter.Use(0, 0, common.GAS_ENCODE) // Gas for encoding the result
+	encoded, err := abi.Encode((*uint256.Int)(&initv))
+	return encoded, err == nil, gasMeter.TotalGasUsed
 }
 
 // Add adds a positive delta to the variable's delta.
-func (this *U256CumHandler) add(caller evmcommon.Address, input []byte) ([]byte, bool, int64) {
-	return this.set(caller, input, true)
+func (this *U256CumHandler) add(caller evmcommon.Address, key string, input []byte) ([]byte, bool, int64) {
+	return this.set(caller, key, input, true)
 }
 
 // Add adds a negative delta to the variable's delta.
-func (this *U256CumHandler) sub(caller evmcommon.Address, input []byte) ([]byte, bool, int64) {
-	return this.set(caller, input, false)
+func (this *U256CumHandler) sub(caller evmcommon.Address, key string, input []byte) ([]byte, bool, int64) {
+	return this.set(caller, key, input, false)
 }
 
-func (this *U256CumHandler) set(caller evmcommon.Address, input []byte, isPositive bool) ([]byte, bool, int64) {
-	path := this.connector.Key(caller) // Build container path
-	if len(path) == 0 {
-		return []byte{}, false, 0
-	}
+func (this *U256CumHandler) set(caller evmcommon.Address, key string, input []byte, isPositive bool) ([]byte, bool, int64) {
+	gasMeter := common.NewGasMeter()
 
 	delta, err := abi.Decode(input, 0, &uint256.Int{}, 1, 32)
+	gasMeter.Use(0, 0, common.GAS_DECODE) // Gas for decoding the delta
 	if err != nil {
-		return []byte{}, false, 0
+		return []byte{}, false, gasMeter.TotalGasUsed
 	}
 
 	value := commutative.NewU256Delta(delta.(*uint256.Int), isPositive)
 
 	txIndex := this.api.GetEU().(interface{ ID() uint64 }).ID()
-	keyPath := path + string(this.key) // Element ID
-	_, err = this.api.WriteCache().(*cache.WriteCache).Write(txIndex, keyPath, value)
-	return []byte{}, err == nil, 0
+	keyPath := this.connector.Key(caller) + key // Variable ID
+	writeDataSize, err := this.api.WriteCache().(*cache.WriteCache).Write(txIndex, keyPath, value)
+	gasMeter.Use(0, writeDataSize, common.GAS_DELTA_WRITE) // Gas for writing the delta
+	return []byte{}, err == nil, gasMeter.TotalGasUsed
 }
 
-func (this *U256CumHandler) min(caller evmcommon.Address, input []byte) ([]byte, bool, int64) {
-	path := this.connector.Key(caller) // Build container path
-	if len(path) == 0 {
-		return []byte{}, false, 0
-	}
+func (this *U256CumHandler) min(caller evmcommon.Address, key string, input []byte) ([]byte, bool, int64) {
+	return this.limit(caller, key, true)
+}
 
-	// Min and Max are read only variable
-	txIndex := this.api.GetEU().(interface{ ID() uint64 }).ID()
-	keyPath := path + string(this.key) // Element ID
-	if value, _, _ := this.api.WriteCache().(*cache.WriteCache).FindForRead(txIndex, keyPath, new(commutative.U256), nil); value != nil {
-		rawmin, _ := value.(*commutative.U256).Limits()
-		minv := rawmin.(uint256.Int)
-		if encoded, err := abi.Encode((*uint256.Int)(&minv)); err == nil { // Encode the result
-			return encoded, true, 0
-		}
-	}
-	return []byte{}, false, 0
+func (this *U256CumHandler) max(caller evmcommon.Address, key string, input []byte) ([]byte, bool, int64) {
+	return this.limit(caller, key, false)
 }
 
-func (this *U256CumHandler) max(caller evmcommon.Address, input []byte) ([]byte, bool, int64) {
-	path := this.connector.Key(caller) // Build container path
-	if len(path) == 0 {
-		return []byte{}, false, 0
-	}
+// Limit gets the lower or the upper bound of the variable, Min and Max are read only.
+func (this *U256CumHandler) limit(caller evmcommon.Address, key string, isMin bool) ([]byte, bool, int64) {
+	gasMeter := common.NewGasMeter()
 
 	txIndex := this.api.GetEU().(interface{ ID() uint64 }).ID()
-	keyPath := path + string(this.key) // Element ID
-	if value, _, _ := this.api.WriteCache().(*cache.WriteCache).FindForRead(txIndex, keyPath, new(commutative.U256), nil); value != nil {
-		_, rawmax := value.(*commutative.U256).Limits()
-		maxv := rawmax.(uint256.Int)
-		if encoded, err := abi.Encode((*uint256.Int)(&maxv)); err == nil { // Encode the result
-			return encoded, true, 0
-		}
+	keyPath := this.connector.Key(caller) + key // Variable ID
+	value, _, _ := this.api.WriteCache().(*cache.WriteCache).FindForRead(txIndex, keyPath, new(commutative.U256), nil)
+	gasMeter.Use(common.DATA_MIN_READ_SIZE, 0, common.GAS_READ) // Gas for reading the bounds
+	if value == nil {
+		return []byte{}, false, gasMeter.TotalGasUsed
 	}
-	return []byte{}, false, 0
+
+	limit, max := value.(*commutative.U256).Limits()
+	if !isMin {
+		limit = max
+	}
+
+	limitv := limit.(uint256.Int)
+	gasMeter.Use(0, 0, common.GAS_ENCODE) // Gas for encoding the result
+	encoded, err := abi.Encode((*uint256.Int)(&limitv))
+	return encoded, err == nil, gasMeter.TotalGasUsed
 }
@@ -0,0 +1,307 @@
+/*
+ *   Copyright (c) 2025 Arcology Network
+
+ *   This program is free software: you can redistribute it and/or modify
+ *   it under the terms of the GNU General Public License as published by
+ *   the Free Software Foundation, either version 3 of the License, or
+ *   (at your option) any later version.
+
+ *   This program is distributed in the hope that it will be useful,
+ *   but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *   GNU General Public License for more details.
+
+ *   You should have received a copy of the GNU General Public License
+ *   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package api
+
+import (
+	"encoding/hex"
+	"math"
+
+	"github.com/arcology-network/common-lib/codec"
+	"github.com/arcology-network/common-lib/types"
+
+	abi "github.com/arcology-network/eu/abi"
+	"github.com/arcology-network/eu/common"
+	cache "github.com/arcology-network/storage-committer/storage/cache"
+	commutative "github.com/arcology-network/storage-committer/type/commutative"
+	evmcommon "github.com/ethereum/go-ethereum/common"
+
+	path "github.com/arcology-network/eu/eth"
+	intf "github.com/arcology-network/eu/interface"
+)
+
+// The variables share the container path with the U256 ones, the prefix keeps them apart.
+const u64KeyPrefix = "u64-"
+
+// The suffix of the counter taking the decrements of a variable.
+const u64DecSuffix = "-dec"
+
+// Uint64CumHandler handles the Uint64Cumulative APIs, a cheaper version of the U256CumHandler for the small counters.
+// The commutative uint64s only take increments, so a variable is backed by two bounded commutative uint64s, one adding
+// up the increments and the other one the decrements. Both start from their lower bounds, which the storage doesn't
+// check, so the lower bound of the variable is kept in the first one and the upper bound in the second one.
+//
+// The bounds are checked against the local view of the transaction, like the U256s. Unlike the U256s, the storage
+// doesn't check them again at commit time, so the concurrent transactions can push a variable out of its bounds,
+// reading it fails then.
+type Uint64CumHandler struct {
+	api       intf.EthApiRouter
+	connector *path.PathBuilder
+	key       string // ID of the default variable
+}
+
+func NewUint64CumulativeHandler(api intf.EthApiRouter) *Uint64CumHandler {
+	k := [20]byte{}
+	return &Uint64CumHandler{
+		api:       api,
+		connector: path.NewPathBuilder("/storage/container", api),
+		key:       u64KeyPrefix + hex.EncodeToString(k[:]),
+	}
+}
+
+func (this *Uint64CumHandler) Address() [20]byte {
+	return common.CUMULATIVE_U64_HANDLER
+}
+
+func (this *Uint64CumHandler) Call(caller, callee [20]byte, input []byte, origin [20]byte, nonce uint64, isReadOnly bool) ([]byte, bool, int64) {
+	signature := codec.Bytes4{}.FromBytes(input)
+
+	if isReadOnly {
+		switch signature {
+		case [4]byte{0x59, 0xe0, 0x2d, 0xd7}: // peek()
+			return this.peek(caller, this.key, input[4:])
+
+		case [4]byte{0x6d, 0x4c, 0xe6, 0x3c}: // get()
+			return this.get(caller, this.key, input[4:])
+
+		case [4]byte{0xf8, 0x89, 0x79, 0x45}: // min()
+			return this.min(caller, this.key, input[4:])
+
+		case [4]byte{0x6a, 0xc5, 0xdb, 0x19}: // max()
+			return this.max(caller, this.key, input[4:])
+
+		case [4]byte{0x7f, 0x86, 0xd1, 0xeb}: // peek(bytes32)
+			return this.named(caller, input[4:], this.peek)
+
+		case [4]byte{0x8e, 0xaa, 0x6a, 0xc0}: // get(bytes32)
+			return this.named(caller, input[4:], this.get)
+
+		case [4]byte{0x3d, 0x98, 0xc9, 0x59}: // min(bytes32)
+			return this.named(caller, input[4:], this.min)
+
+		case [4]byte{0xa1, 0x62, 0x7f, 0xa9}: // max(bytes32)
+			return this.named(caller, input[4:], this.max)
+		}
+	} else {
+		switch signature {
+		case [4]byte{0xc4, 0x62, 0x0f, 0x5f}: // new(uint64,uint64)
+			return this.new(caller, this.key, input[4:])
+
+		case [4]byte{0x7b, 0x88, 0x11, 0x96}: // add(uint64)
+			return this.add(caller, this.key, input[4:])
+
+		case [4]byte{0xb5, 0x3a, 0x6a, 0x72}: // sub(uint64)
+			return this.sub(caller, this.key, input[4:])
+
+		case [4]byte{0x5e, 0x41, 0x43, 0xdc}: // new(bytes32,uint64,uint64)
+			return this.named(caller, input[4:], this.new)
+
+		case [4]byte{0xd3, 0xa1, 0x80, 0xa8}: // add(bytes32,uint64)
+			return this.named(caller, input[4:], this.add)
+
+		case [4]byte{0xc4, 0xe0, 0x2b, 0x7d}: // sub(bytes32,uint64)
+			return this.named(caller, input[4:], this.sub)
+		}
+	}
+	return []byte{}, false, common.GAS_CALL_UNKNOW
+}
+
+// Named calls the function on the variable whose ID is the first argument, see U256CumHandler.named().
+func (this *Uint64CumHandler) named(caller evmcommon.Address, input []byte, f func(evmcommon.Address, string, []byte) ([]byte, bool, int64)) ([]byte, bool, int64) {
+	if len(input) < 32 {
+		return []byte{}, false, common.GAS_DECODE
+	}
+	return f(caller, u64KeyPrefix+hex.EncodeToString(input[:32]), input[32:])
+}
+
+// counter gets the total and the lower bound of one of the two counters, from the local view or the committed one.
+func (this *Uint64CumHandler) counter(path string, isCommitted bool) (uint64, uint64, bool) {
+	var total, typedv any
+	if isCommitted {
+		typedv, _ = this.api.WriteCache().(*cache.WriteCache).PeekCommitted(path, new(commutative.Uint64))
+		if counter, ok := typedv.(*commutative.Uint64); ok {
+			total = counter.Value()
+		}
+	} else {
+		total, typedv, _ = this.api.WriteCache().(*cache.WriteCache).Peek(path, new(commutative.Uint64))
+	}
+
+	counter, ok := typedv.(*commutative.Uint64)
+	if !ok || total == nil {
+		return 0, 0, false
+	}
+
+	lower, _ := counter.Limits()
+	return total.(uint64), lower.(uint64), true
+}
+
+// variable gets the value and the bounds of the variable from its two counters.
+func (this *Uint64CumHandler) variable(keyPath string, isCommitted bool) (uint64, uint64, uint64, bool) {
+	incs, min, incOk := this.counter(keyPath, isCommitted)
+	decs, max, decOk := this.counter(keyPath+u64DecSuffix, isCommitted)
+	if !incOk || !decOk {
+		return 0, 0, 0, false
+	}
+
+	adds, subs := incs-min, decs-max
+	if subs > adds || adds-subs > max-min {
+		return 0, min, max, false // Pushed out of the bounds by the concurrent transactions
+	}
+	return min + adds - subs, min, max, true
+}
+
+func (this *Uint64CumHandler) new(caller evmcommon.Address, key string, input []byte) ([]byte, bool, int64) {
+	gasMeter := common.NewGasMeter()
+
+	txIndex := this.api.GetEU().(interface{ ID() uint64 }).ID()
+	if ok, _ := this.connector.CreateNewAccount(txIndex, types.Address(codec.Bytes20(caller).Hex())); !ok { // A new container
+		return []byte{}, false, gasMeter.TotalGasUsed
+	}
+
+	min, minErr := abi.Decode(input, 0, uint64(0), 1, 32)
+	max, maxErr := abi.Decode(input, 1, uint64(0), 1, 32)
+	gasMeter.Use(0, 0, common.GAS_DECODE*2) // Gas for decoding the bounds
+	if minErr != nil || maxErr != nil || min.(uint64) > max.(uint64) {
+		return []byte{}, false, gasMeter.TotalGasUsed
+	}
+
+	// The increments start from the lower bound and the decrements from the upper bound.
+	keyPath := this.connector.Key(caller) + key // Variable ID
+	for _, counter := range []struct {
+		path  string
+		start uint64
+	}{{keyPath, min.(uint64)}, {keyPath + u64DecSuffix, max.(uint64)}} {
+		newUint64 := commutative.NewBoundedUint64(counter.start, math.MaxUint64)
+		newUint64.(*commutative.Uint64).SetValue(counter.start)
+		writeDataSize, err := this.api.WriteCache().(*cache.WriteCache).Write(txIndex, counter.path, newUint64)
+		gasMeter.Use(0, writeDataSize, common.GAS_CUMULATIVE_U64_WRITE) // Gas for creating the counter
+		if err != nil {
+			return []byte{}, false, gasMeter.TotalGasUsed
+		}
+	}
+	return []byte{}, true, gasMeter.TotalGasUsed
+}
+
+func (this *Uint64CumHandler) get(caller evmcommon.Address, key string, input []byte) ([]byte, bool, int64) {
+	gasMeter := common.NewGasMeter()
+
+	txIndex := this.api.GetEU().(interface{ ID() uint64 }).ID()
+	keyPath := this.connector.Key(caller) + key // Variable ID
+	for _, path := range []string{keyPath, keyPath + u64DecSuffix} {
+		_, _, readDataSize := this.api.WriteCache().(*cache.WriteCache).Read(txIndex, path, new(commutative.Uint64))
+		gasMeter.Use(readDataSize, 0, common.GAS_CUMULATIVE_U64_READ) // Gas for reading the counter
+	}
+
+	value, _, _, ok := this.variable(keyPath, false)
+	if !ok {
+		return []byte{}, false, gasMeter.TotalGasUsed
+	}
+
+	gasMeter.Use(0, 0, common.GAS_ENCODE) // Gas for encoding the result
+	encoded, err := abi.Encode(value)
+	return encoded, err == nil, gasMeter.TotalGasUsed
+}
+
+// Peek reads the initial value from the WriteCache, see U256CumHandler.peek().
+func (this *Uint64CumHandler) peek(caller evmcommon.Address, key string, input []byte) ([]byte, bool, int64) {
+	gasMeter := common.NewGasMeter()
+
+	keyPath := this.connector.Key(caller) + key // Variable ID
+	initv, _, _, ok := this.variable(keyPath, true)
+	gasMeter.Use(common.DATA_MIN_READ_SIZE, 0, common.GAS_CUMULATIVE_U64_READ*2) // Gas for reading the committed counters
+	if !ok {
+		return []byte{}, false, gasMeter.TotalGasUsed
+	}
+
+	gasMeter.Use(0, 0, common.GAS_ENCODE) // Gas for encoding the result
+	encoded, err := abi.Encode(initv)
+	return encoded, err == nil, gasMeter.TotalGasUsed
+}
+
+// Add adds the delta to the increments, it fails if the value would go above the upper bound.
+func (this *Uint64CumHandler) add(caller evmcommon.Address, key string, input []byte) ([]byte, bool, int64) {
+	return this.set(caller, key, input, true)
+}
+
+// Sub adds the delta to the decrements, it fails if the value would go below the lower bound.
+func (this *Uint64CumHandler) sub(caller evmcommon.Address, key string, input []byte) ([]byte, bool, int64) {
+	return this.set(caller, key, input, false)
+}
+
+func (this *Uint64CumHandler) set(caller evmcommon.Address, key string, input []byte, isPositive bool) ([]byte, bool, int64) {
+	gasMeter := common.NewGasMeter()
+
+	delta, err := abi.Decode(input, 0, uint64(0), 1, 32)
+	gasMeter.Use(0, 0, common.GAS_DECODE) // Gas for decoding the delta
+	if err != nil {
+		return []byte{}, false, gasMeter.TotalGasUsed
+	}
+
+	// Only peek at the counters to check the bounds, a recorded read would make the concurrent updates conflict.
+	keyPath := this.connector.Key(caller) + key // Variable ID
+	value, min, max, ok := this.variable(keyPath, false)
+	gasMeter.Use(common.DATA_MIN_READ_SIZE, 0, common.GAS_CUMULATIVE_U64_READ*2) // Gas for checking the bounds
+	if !ok {
+		return []byte{}, false, gasMeter.TotalGasUsed
+	}
+
+	counterPath := keyPath
+	if isPositive && delta.(uint64) > max-value {
+		return []byte{}, false, gasMeter.TotalGasUsed
+	}
+
+	if !isPositive {
+		if delta.(uint64) > value-min {
+			return []byte{}, false, gasMeter.TotalGasUsed
+		}
+		counterPath = keyPath + u64DecSuffix
+	}
+
+	txIndex := this.api.GetEU().(interface{ ID() uint64 }).ID()
+	writeDataSize, err := this.api.WriteCache().(*cache.WriteCache).Write(txIndex, counterPath, commutative.NewUint64Delta(delta.(uint64)))
+	gasMeter.Use(0, writeDataSize, common.GAS_CUMULATIVE_U64_WRITE) // Gas for writing the delta
+	return []byte{}, err == nil, gasMeter.TotalGasUsed
+}
+
+func (this *Uint64CumHandler) min(caller evmcommon.Address, key string, input []byte) ([]byte, bool, int64) {
+	return this.limit(caller, key, true)
+}
+
+func (this *Uint64CumHandler) max(caller evmcommon.Address, key string, input []byte) ([]byte, bool, int64) {
+	return this.limit(caller, key, false)
+}
+
+// Limit gets the lower or the upper bound of the variable, they are read only.
+func (this *Uint64CumHandler) limit(caller evmcommon.Address, key string, isMin bool) ([]byte, bool, int64) {
+	gasMeter := common.NewGasMeter()
+
+	keyPath := this.connector.Key(caller) + key // Variable ID
+	_, limit, minOk := this.counter(keyPath, false)
+	_, max, maxOk := this.counter(keyPath+u64DecSuffix, false)
+	gasMeter.Use(common.DATA_MIN_READ_SIZE, 0, common.GAS_CUMULATIVE_U64_READ*2) // Gas for reading the bounds
+	if !minOk || !maxOk {
+		return []byte{}, false, gasMeter.TotalGasUsed
+	}
+
+	if !isMin {
+		limit = max
+	}
+
+	gasMeter.Use(0, 0, common.GAS_ENCODE) // Gas for encoding the result
+	encoded, err := abi.Encode(limit)
+	return encoded, err == nil, gasMeter.TotalGasUsed
+}
@@ -74,3 +74,45 @@ func DecodeCallReturns(data []byte) ([]CallResult, error) {
 
 	return results, nil
 }
+
+// JobResult is the detailed result of a job, a job that lost a conflict isn't successful and has Conflicted set.
+type JobResult struct {
+	Success      bool
+	Conflicted   bool
+	GasUsed      uint64
+	RevertReason string
+	SubTxHash    [32]byte
+}
+
+var jobResultComponents = []abi.ArgumentMarshaling{
+	{Name: "success", Type: "bool"},
+	{Name: "conflicted", Type: "bool"},
+	{Name: "gasUsed", Type: "uint64"},
+	{Name: "revertReason", Type: "string"},
+	{Name: "subTxHash", Type: "bytes32"},
+}
+
+func EncodeJobResults(results []JobResult) ([]byte, error) {
+	tupleArrayType, err := abi.NewType("tuple[]", "", jobResultComponents)
+	if err != nil {
+		return nil, err
+	}
+	return abi.Arguments{{Type: tupleArrayType}}.Pack(results)
+}
+
+func DecodeJobResults(data []byte) ([]JobResult, error) {
+	tupleArrayType, err := abi.NewType("tuple[]", "", jobResultComponents)
+	if err != nil {
+		return nil, err
+	}
+
+	args := abi.Arguments{{Type: tupleArrayType}}
+	values, err := args.Unpack(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []JobResult
+	err = args.Copy(&results, values)
+	return results, err
+}
@@ -21,6 +21,7 @@ import (
 	"bytes"
 	"fmt"
 	"log"
+	"reflect"
 	"testing"
 )
 
@@ -53,3 +54,25 @@ func TestEncoder(t *testing.T) {
 		t.Errorf("Expected return data to be 0x01, 0x02, 0x03 but got %v", results)
 	}
 }
+
+func TestJobResultsCodec(t *testing.T) {
+	results := []JobResult{
+		{Success: true, GasUsed: 21000, SubTxHash: [32]byte{1}},
+		{Conflicted: true, GasUsed: 30000, SubTxHash: [32]byte{2}},
+		{GasUsed: 25000, RevertReason: "insufficient balance", SubTxHash: [32]byte{3}},
+	}
+
+	encoded, err := EncodeJobResults(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DecodeJobResults(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(results, decoded) {
+		t.Errorf("Expected %v, got %v", results, decoded)
+	}
+}
@@ -18,12 +18,15 @@
 package multiprocessor
 
 import (
+	"context"
 	"math"
 	"math/big"
 	"sync/atomic"
 
+	"github.com/arcology-network/common-lib/codec"
 	"github.com/arcology-network/common-lib/common"
 	"github.com/arcology-network/common-lib/exp/slice"
+	stgcommon "github.com/arcology-network/storage-committer/common"
 	univalue "github.com/arcology-network/storage-committer/type/univalue"
 
 	cache "github.com/arcology-network/storage-committer/storage/cache"
@@ -54,7 +57,26 @@ func NewMultiprocessHandler(ethApiRouter intf.EthApiRouter) *MultiprocessHandler
 
 func (this *MultiprocessHandler) Address() [20]byte { return eucommon.MULTIPROCESS_HANDLER }
 
+// Call handles runDetailed(bytes) and leaves the rest to the base handlers, the unknown selectors go to Run.
+func (this *MultiprocessHandler) Call(caller, callee [20]byte, input []byte, origin [20]byte, nonce uint64, isFromStaticCall bool) ([]byte, bool, int64) {
+	signature := codec.Bytes4{}.FromBytes(input)
+	if signature == [4]byte{0x23, 0x25, 0xf5, 0x29} { // runDetailed(bytes)
+		if isFromStaticCall {
+			return []byte{}, false, eucommon.GAS_CALL_UNKNOW // State changes aren't allowed in a STATICCALL
+		}
+		return this.run(caller, input[4:], true)
+	}
+	return this.BaseHandlers.Call(caller, callee, input, origin, nonce, isFromStaticCall)
+}
+
+// Run the jobs in the container and return (success, returnData)[].
 func (this *MultiprocessHandler) Run(caller, callee [20]byte, input []byte, args ...interface{}) ([]byte, bool, int64) {
+	return this.run(caller, input, false)
+}
+
+// Run the jobs in the container, the detailed results also have the conflict flags, the gas used, the revert
+// reasons and the sub transaction hashes of the jobs.
+func (this *MultiprocessHandler) run(caller [20]byte, input []byte, detailed bool) ([]byte, bool, int64) {
 	accumFee := int64(0)
 
 	accumFee += eucommon.GAS_DECODE
@@ -73,7 +95,7 @@ func (this *MultiprocessHandler) Run(caller, callee [20]byte, input []byte, args
 	if err != nil {
 		return []byte{}, false, 0
 	}
-	threads := common.Min(common.Max(uint8(numThreads), 1), math.MaxUint8) // [1, 255]
+	threads := uint8(common.Min(common.Max(numThreads, 1), math.MaxUint8)) // [1, 255]
 
 	path := this.Connector().Key(caller)
 	length, successful, fee := this.FullLength(path)
@@ -105,8 +127,9 @@ func (this *MultiprocessHandler) Run(caller, callee [20]byte, input []byte, args
 	subConfig := eucommon.NewConfigFromBlockContext(this.Api().GetEU().(interface{ VM() any }).VM().(*vm.EVM).Context)
 	newGen := eu.NewGenerationFromMsgs(0, threads, ethMsgs, this.Api())
 
-	// Run the job sequences in parallel.
-	transitions := newGen.Execute(subConfig, this.Api())
+	// Run the job sequences in parallel, they are bound to the context of the parent job.
+	ctx := this.Api().GetEU().(interface{ Context() context.Context }).Context()
+	transitions := newGen.ExecuteWithContext(ctx, subConfig, this.Api())
 
 	// Unify tx IDs
 	mainTxID := uint64(this.Api().GetEU().(interface{ ID() uint64 }).ID())
@@ -117,16 +140,35 @@ func (this *MultiprocessHandler) Run(caller, callee [20]byte, input []byte, args
 	returnValues := make([][]byte, length)
 	successes := make([]bool, length)
 	inConflict := make([]bool, length)
+	jobResults := make([]JobResult, 0, length)
 	totalSubExecGasUsed := uint64(0) // The total gas used by the sub processes
 	for i, seq := range newGen.JobSeqs() {
 		// only one job per sequence for multiprocessing
-		successes[i] = seq.Jobs[0].Results.Receipt.Status == 1 // Check if the transaction was successful
-		inConflict[i] = seq.Jobs[0].Results.Err != nil
-		returnValues[i] = seq.Jobs[0].Results.EvmResult.Return()
-		totalSubExecGasUsed += uint64(seq.Jobs[0].Results.Receipt.GasUsed) // Get the gas used by the transaction
+		results := seq.Jobs[0].Results
+		if results == nil || results.Receipt == nil || results.EvmResult == nil { // Never executed
+			jobResults = append(jobResults, JobResult{SubTxHash: seq.Jobs[0].StdMsg.TxHash})
+			continue
+		}
+
+		successes[i] = results.Receipt.Status == 1 // Check if the transaction was successful
+		inConflict[i] = isConflict(results.Err)
+		returnValues[i] = results.EvmResult.Return()
+		totalSubExecGasUsed += uint64(results.Receipt.GasUsed) // Get the gas used by the transaction
+
+		jobResult := JobResult{
+			Success:    successes[i] && !inConflict[i],
+			Conflicted: inConflict[i],
+			GasUsed:    results.Receipt.GasUsed,
+			SubTxHash:  seq.Jobs[0].StdMsg.TxHash,
+		}
+
+		if revert := results.Revert; revert != nil {
+			jobResult.RevertReason = revert.Reason
+		}
+		jobResults = append(jobResults, jobResult)
 
 		// Append the sub logs to the main thread
-		for _, log := range seq.Jobs[0].Results.Receipt.Logs {
+		for _, log := range results.Receipt.Logs {
 			this.Api().VM().(*vm.EVM).StateDB.AddLog(log)
 		}
 	}
@@ -142,6 +184,11 @@ func (this *MultiprocessHandler) Run(caller, callee [20]byte, input []byte, args
 	}
 
 	// Prepare the return values to return for the caller.
+	if detailed {
+		encoded, err := EncodeJobResults(jobResults)
+		return encoded, err == nil, accumFee
+	}
+
 	encodedReturnedData, err := EncodeCallReturns(returnValues, successes)
 	if err != nil {
 		return []byte{}, false, accumFee
@@ -149,6 +196,11 @@ func (this *MultiprocessHandler) Run(caller, callee [20]byte, input []byte, args
 	return encodedReturnedData, true, accumFee
 }
 
+// isConflict checks if the error is the one flagged by the arbitrator, not a failure of the execution itself.
+func isConflict(err error) bool {
+	return err != nil && err.Error() == stgcommon.WARN_ACCESS_CONFLICT
+}
+
 // toEthMsgs converts the input byte slice into a list of ethereum messages.
 func (this *MultiprocessHandler) WrapToEthMsg(caller [20]byte, input []byte) (*evmcore.Message, error) {
 	gasLimit, value, calleeAddr, funCall, err := abi.Parse4(input,
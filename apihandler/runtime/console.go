@@ -0,0 +1,136 @@
+/*
+ *   Copyright (c) 2025 Arcology Network
+
+ *   This program is free software: you can redistribute it and/or modify
+ *   it under the terms of the GNU General Public License as published by
+ *   the Free Software Foundation, either version 3 of the License, or
+ *   (at your option) any later version.
+
+ *   This program is distributed in the hope that it will be useful,
+ *   but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *   GNU General Public License for more details.
+
+ *   You should have received a copy of the GNU General Public License
+ *   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/arcology-network/common-lib/codec"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	evmcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	intf "github.com/arcology-network/eu/interface"
+)
+
+// The key of the console.log entries in the execution logs.
+const CONSOLE_LOG_KEY = "console.log"
+
+// The console.log signatures in Hardhat's console.sol, indexed by their selectors.
+var consoleSignatures = newConsoleSignatures()
+
+// Build the selectors of log() with one argument of each type, and the combinations of up to four arguments
+// of uint256, string, bool and address. The legacy uint and int aliases are included as well.
+func newConsoleSignatures() map[[4]byte]abi.Arguments {
+	signatures := [][]string{{}, {"int256"}, {"bytes"}}
+	for i := 1; i <= 32; i++ {
+		signatures = append(signatures, []string{fmt.Sprintf("bytes%d", i)})
+	}
+
+	combinations := [][]string{{}}
+	for i := 0; i < 4; i++ {
+		next := [][]string{}
+		for _, prefix := range combinations {
+			for _, typ := range []string{"uint256", "string", "bool", "address"} {
+				next = append(next, append(append([]string{}, prefix...), typ))
+			}
+		}
+		signatures, combinations = append(signatures, next...), next
+	}
+
+	dict := map[[4]byte]abi.Arguments{}
+	for _, types := range signatures {
+		args := make(abi.Arguments, len(types))
+		for i, typ := range types {
+			t, _ := abi.NewType(typ, "", nil)
+			args[i] = abi.Argument{Type: t}
+		}
+
+		signature := "log(" + strings.Join(types, ",") + ")"
+		dict[codec.Bytes4{}.FromBytes(crypto.Keccak256([]byte(signature)))] = args
+
+		legacy := strings.NewReplacer("uint256", "uint", "int256", "int").Replace(signature)
+		dict[codec.Bytes4{}.FromBytes(crypto.Keccak256([]byte(legacy)))] = args
+	}
+	return dict
+}
+
+// DecodeConsoleLog decodes the input of a console.log call into the message, the arguments are separated by spaces.
+func DecodeConsoleLog(input []byte) (string, bool) {
+	if len(input) < 4 {
+		return "", false
+	}
+
+	args, ok := consoleSignatures[codec.Bytes4{}.FromBytes(input[:4])]
+	if !ok {
+		return "", false
+	}
+
+	values, err := args.Unpack(input[4:])
+	if err != nil {
+		return "", false
+	}
+
+	strs := make([]string, len(values))
+	for i, v := range values {
+		switch v := v.(type) {
+		case *big.Int:
+			strs[i] = v.String()
+		case evmcommon.Address:
+			strs[i] = v.Hex()
+		case []byte:
+			strs[i] = hexutil.Encode(v)
+		case string, bool:
+			strs[i] = fmt.Sprint(v)
+		default:
+			strs[i] = fmt.Sprintf("0x%x", v) // Fixed size bytes
+		}
+	}
+	return strings.Join(strs, " "), true
+}
+
+// ConsoleLog is an entry of the console.log messages in the execution logs. The calls failed to decode have an
+// error instead of the message.
+type ConsoleLog struct {
+	TxHash  hexutil.Bytes `json:"txHash"`
+	Caller  string        `json:"caller"`
+	Message string        `json:"message"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// Add the message to the execution logs, tagged with the transaction hash.
+func addConsoleLog(api intf.EthApiRouter, caller evmcommon.Address, msg string) {
+	addConsoleEntry(api, &ConsoleLog{Caller: caller.Hex(), Message: msg})
+}
+
+// Log the raw input of a call failed to decode, the call itself still succeeds.
+func addConsoleError(api intf.EthApiRouter, caller evmcommon.Address, input []byte) {
+	addConsoleEntry(api, &ConsoleLog{Caller: caller.Hex(), Error: "Error: Failed to decode " + hexutil.Encode(input)})
+}
+
+func addConsoleEntry(api intf.EthApiRouter, entry *ConsoleLog) {
+	txHash := api.GetEU().(interface{ TxHash() [32]byte }).TxHash()
+	entry.TxHash = txHash[:]
+
+	encoded, _ := json.Marshal(entry)
+	api.AddLog(CONSOLE_LOG_KEY, string(encoded))
+}
@@ -18,8 +18,6 @@
 package runtime
 
 import (
-	"fmt"
-
 	"github.com/arcology-network/eu/common"
 	intf "github.com/arcology-network/eu/interface"
 )
@@ -39,14 +37,18 @@ func (this *IoHandlers) Address() [20]byte {
 }
 
 func (this *IoHandlers) Call(caller, callee [20]byte, input []byte, origin [20]byte, nonce uint64, _ bool) ([]byte, bool, int64) {
-	// signature := codec.Bytes4{}.FromBytes(input[:])
 	return this.print(caller, callee, input, origin, nonce)
 }
 
-func (this *IoHandlers) print(caller, callee [20]byte, input []byte, origin [20]byte, nonce uint64) ([]byte, bool, int64) {
-	fmt.Println("caller:", caller)
-	fmt.Println("input:", input)
-	fmt.Println("origin:", origin)
-	fmt.Println("nonce:", nonce)
-	return []byte{}, true, 0
+// Decode the console.log calls in Hardhat's format and add them to the execution logs, nothing goes to stdout.
+// Logging never fails the call, the input failed to decode is logged instead.
+func (this *IoHandlers) print(caller, _ [20]byte, input []byte, _ [20]byte, _ uint64) ([]byte, bool, int64) {
+	msg, ok := DecodeConsoleLog(input)
+	if !ok {
+		addConsoleError(this.api, caller, input)
+		return []byte{}, true, common.GAS_DECODE
+	}
+
+	addConsoleLog(this.api, caller, msg)
+	return []byte{}, true, common.GAS_DECODE
 }
@@ -19,7 +19,6 @@ package runtime
 
 import (
 	"encoding/hex"
-	"fmt"
 	"math"
 
 	"github.com/arcology-network/common-lib/codec"
@@ -75,9 +74,15 @@ func (this *RuntimeHandlers) Call(caller, callee [20]byte, input []byte, origin
 		return this.uuid(caller, callee, input[4:])
 
 	case [4]byte{0x0f, 0x0d, 0x97, 0xaa}: //
+		if isReadOnly {
+			return []byte{}, false, eucommon.GAS_CALL_UNKNOW // State changes aren't allowed in a STATICCALL
+		}
 		return this.setParallelism(caller, callee, input[4:])
 
 	case [4]byte{0xac, 0x8f, 0x58, 0xf3}: // 1c 2f 3b 6d	case [4]byte{0xac, 0x8f, 0x58, 0xf3}: // 19 7f 62 5f
+		if isReadOnly {
+			return []byte{}, false, eucommon.GAS_CALL_UNKNOW // State changes aren't allowed in a STATICCALL
+		}
 		return this.deferCall(caller, callee, input[4:])
 
 	case [4]byte{0x21, 0xcb, 0x6b, 0xc3}: // bb 07 e8 5d
@@ -87,7 +92,6 @@ func (this *RuntimeHandlers) Call(caller, callee [20]byte, input []byte, origin
 		return this.print(caller, callee, input[4:])
 	}
 
-	fmt.Println(input)
 	return []byte{}, false, eucommon.GAS_CALL_UNKNOW
 }
 
@@ -250,7 +254,10 @@ func (this *RuntimeHandlers) deferCall(caller, callee evmcommon.Address, input [
 func (this *RuntimeHandlers) print(caller, _ evmcommon.Address, input []byte) ([]byte, bool, int64) {
 	msg, err := abi.DecodeTo(input, 2, []uint8{}, 1, math.MaxInt)
 
-	msg = evmcommon.TrimRightZeroes(msg)
-	fmt.Println("From=", caller, " Msg=", (msg), " Error=", err)
+	if err != nil {
+		addConsoleError(this.api, caller, input)
+	} else {
+		addConsoleLog(this.api, caller, string(evmcommon.TrimRightZeroes(msg)))
+	}
 	return []byte{}, true, eucommon.GAS_SET_RUNTIME_INFO * 10
 }
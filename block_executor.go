@@ -0,0 +1,129 @@
+/*
+ *   Copyright (c) 2025 Arcology Network
+
+ *   This program is free software: you can redistribute it and/or modify
+ *   it under the terms of the GNU General Public License as published by
+ *   the Free Software Foundation, either version 3 of the License, or
+ *   (at your option) any later version.
+
+ *   This program is distributed in the hope that it will be useful,
+ *   but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *   GNU General Public License for more details.
+
+ *   You should have received a copy of the GNU General Public License
+ *   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package execution
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	commontype "github.com/arcology-network/common-lib/types"
+	eucommon "github.com/arcology-network/eu/common"
+	intf "github.com/arcology-network/eu/interface"
+	univalue "github.com/arcology-network/storage-committer/type/univalue"
+	evmtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+var ErrMissingBlockNumber = errors.New("Error: The block number isn't set in the config")
+
+// BlockResult contains everything a node needs after executing the transactions of a block.
+type BlockResult struct {
+	Receipts    []*evmtypes.Receipt  // Receipts of the transactions included in the block, in the block order.
+	Results     []*eucommon.Result   // Results of all the transactions, including the ones failed the prechecks.
+	GasUsed     uint64               // Total gas used by the block.
+	Bloom       evmtypes.Bloom       // Block level bloom filter of all the logs.
+	Transitions []*univalue.Univalue // Merged cleared transitions, ready for the storage committer.
+}
+
+// BlockExecutor executes the transactions of a block on top of a Generation, one job sequence for each transaction.
+type BlockExecutor struct {
+	config     *eucommon.Config
+	numThreads uint8
+	maxRetries int
+	policy     ConflictPolicy
+	pool       *eucommon.EUPool // Reusable EUs shared by the blocks executed.
+}
+
+func NewBlockExecutor(config *eucommon.Config, numThreads uint8) *BlockExecutor {
+	return &BlockExecutor{
+		config:     config,
+		numThreads: numThreads,
+		pool:       eucommon.NewEUPool(int(numThreads)),
+	}
+}
+
+// SetMaxRetries sets the max rounds to re-execute the conflicted transactions, see Generation.SetMaxRetries.
+func (this *BlockExecutor) SetMaxRetries(rounds int) *BlockExecutor {
+	this.maxRetries = rounds
+	return this
+}
+
+// SetConflictPolicy sets the policy to pick the winners among the conflicting transactions.
+func (this *BlockExecutor) SetConflictPolicy(policy ConflictPolicy) *BlockExecutor {
+	this.policy = policy
+	return this
+}
+
+func (this *BlockExecutor) Config() *eucommon.Config { return this.config }
+
+// Execute executes the messages of a block on top of the blockAPI. The messages need to be in the block order with
+// their IDs set. The transactions failed the prechecks don't have receipts, their errors are in the PrecheckErr
+// of the results. The config needs a block number for the receipts and the logs.
+func (this *BlockExecutor) Execute(ctx context.Context, msgs []*commontype.StandardMessage, blockAPI intf.EthApiRouter) (*BlockResult, error) {
+	if this.config.BlockNumber == nil {
+		return nil, ErrMissingBlockNumber
+	}
+
+	jobSeqs := make([]*eucommon.JobSequence, len(msgs))
+	for i, msg := range msgs {
+		jobSeqs[i] = new(eucommon.JobSequence).New(uint64(i), blockAPI).AppendMsg(msg)
+	}
+
+	gen := NewGeneration(0, this.numThreads, jobSeqs).SetMaxRetries(this.maxRetries).SetConflictPolicy(this.policy).SetEUPool(this.pool)
+	result := &BlockResult{
+		Transitions: gen.ExecuteWithContext(ctx, this.config, blockAPI),
+		Results:     make([]*eucommon.Result, 0, len(jobSeqs)),
+		Receipts:    make([]*evmtypes.Receipt, 0, len(jobSeqs)),
+	}
+
+	logIndex := uint(0)
+	for _, seq := range jobSeqs {
+		job := seq.Jobs[0]
+		result.Results = append(result.Results, job.Results)
+
+		// The transactions failed the prechecks are invalid, they aren't part of the block.
+		receipt := job.Results.Receipt
+		if job.Results.PrecheckErr != nil || receipt == nil {
+			continue
+		}
+
+		// The EVM succeeded but the transitions were dropped, because of a conflict or a timeout.
+		if job.Results.Err != nil {
+			receipt.Status = evmtypes.ReceiptStatusFailed
+			receipt.Logs = []*evmtypes.Log{}
+		}
+
+		result.GasUsed += receipt.GasUsed
+		receipt.CumulativeGasUsed = result.GasUsed
+		receipt.TransactionIndex = uint(len(result.Receipts))
+		receipt.BlockNumber = new(big.Int).Set(this.config.BlockNumber)
+
+		for _, log := range receipt.Logs {
+			log.TxHash = receipt.TxHash
+			log.TxIndex = receipt.TransactionIndex
+			log.BlockNumber = this.config.BlockNumber.Uint64()
+			log.Index = logIndex
+			logIndex++
+		}
+		receipt.Bloom = evmtypes.CreateBloom(evmtypes.Receipts{receipt})
+		result.Receipts = append(result.Receipts, receipt)
+	}
+
+	result.Bloom = evmtypes.CreateBloom(result.Receipts)
+	return result, nil
+}
@@ -20,6 +20,7 @@ package common
 import (
 	"math"
 	"math/big"
+	"time"
 
 	adaptorintf "github.com/arcology-network/eu/interface"
 	"github.com/ethereum/go-ethereum/common"
@@ -45,8 +46,9 @@ type Config struct {
 	Time        *big.Int    // types.Header.Time
 	Chain       adaptorintf.ChainContext
 	Coinbase    *evmcommon.Address
-	GasLimit    uint64   // types.Header.GasLimit
-	Difficulty  *big.Int // types.Header.Difficulty
+	GasLimit    uint64        // types.Header.GasLimit
+	Difficulty  *big.Int      // types.Header.Difficulty
+	JobTimeout  time.Duration // Max wall-clock time for a single job, 0 for no limit.
 }
 
 func (this *Config) SetCoinbase(coinbase evmcommon.Address) *Config {
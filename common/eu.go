@@ -19,6 +19,8 @@ package common
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"math"
 	"math/big"
@@ -35,11 +37,15 @@ import (
 	"github.com/ethereum/go-ethereum/params"
 )
 
+// ErrExecutionTimeout is the error for the jobs cancelled or skipped because their context was done.
+var ErrExecutionTimeout = errors.New("Error: Execution timeout")
+
 type EU struct {
 	job         *Job              // The job that is being executed
 	evm         *vm.EVM           // Original ETH EVM
 	statedb     vm.StateDB        // Arcology Implementation of Eth StateDB
 	api         intf.EthApiRouter // Arcology API calls
+	ctx         context.Context   // The context of the job being executed, nil outside RunWithContext()
 	ChainConfig *params.ChainConfig
 	VmConfig    vm.Config
 }
@@ -68,6 +74,14 @@ func (this *EU) Coinbase() [20]byte { return this.evm.Context.Coinbase }
 func (this *EU) Origin() [20]byte   { return this.evm.TxContext.Origin }
 func (this *EU) Job() *Job          { return this.job }
 
+// Context returns the context of the job being executed, the sub processes spawned by the job are bound to it.
+func (this *EU) Context() context.Context {
+	if this.ctx == nil {
+		return context.Background()
+	}
+	return this.ctx
+}
+
 func (this *EU) Message() interface{}            { return this.job.StdMsg }
 func (this *EU) VM() interface{}                 { return this.evm }
 func (this *EU) Statedb() vm.StateDB             { return this.statedb }
@@ -82,6 +96,11 @@ func (this *EU) SetRuntimeContext(statedb vm.StateDB, api intf.EthApiRouter) {
 	this.evm.ArcologyAPIs.APIs = api
 }
 
+// finaliser is implemented by the statedbs cleaning up at the end of each transaction, see eth.ImplStateDB.
+type finaliser interface {
+	Finalise()
+}
+
 func (this *EU) Run(job *Job, blockContext vm.BlockContext, txContext vm.TxContext) (*evmcoretypes.Receipt, *evmcore.ExecutionResult, error) {
 	this.statedb.(*eth.ImplStateDB).PrepareFormer(job.StdMsg.TxHash, ethcommon.Hash{}, uint64(job.StdMsg.ID))
 	this.evm.Context = blockContext
@@ -90,6 +109,9 @@ func (this *EU) Run(job *Job, blockContext vm.BlockContext, txContext vm.TxConte
 
 	gasPool := core.GasPool(math.MaxUint64)
 	result, err := core.ApplyMessage(this.evm, this.job.StdMsg.Native, &gasPool) // Execute the transcation
+	if statedb, ok := this.statedb.(finaliser); ok {
+		statedb.Finalise() // Remove the self-destructed accounts
+	}
 
 	if err != nil {
 		result = &core.ExecutionResult{
@@ -100,9 +122,8 @@ func (this *EU) Run(job *Job, blockContext vm.BlockContext, txContext vm.TxConte
 		fmt.Printf("--------eu/eu.go----core.ApplyMessage result.err:%v\n", result.Err)
 	}
 
-	assertLog := GetAssertion(result.ReturnData) // Get the assertion
-	if len(assertLog) > 0 {
-		this.api.AddLog("assert", assertLog)
+	if revert := DecodeRevert(result.Revert()); revert != nil {
+		this.api.AddLog("revert", revert.String()) // The decoded revert reason in JSON
 	}
 
 	// Create a new receipt
@@ -124,23 +145,30 @@ func (this *EU) Run(job *Job, blockContext vm.BlockContext, txContext vm.TxConte
 	return receipt, result, err
 }
 
-// Get the assertion info from the execution result
+// RunWithContext executes the job like Run, but cancels the EVM once the context is done. A cancelled job is marked with
+// ErrExecutionTimeout and its state changes are discarded except for the ones immune to the execution status.
+func (this *EU) RunWithContext(ctx context.Context, job *Job, blockContext vm.BlockContext, txContext vm.TxContext) (*evmcoretypes.Receipt, *evmcore.ExecutionResult, error) {
+	stop := context.AfterFunc(ctx, this.evm.Cancel) // Cancel the EVM from another goroutine when the context is done.
+	defer stop()
+
+	this.ctx = ctx
+	defer func() { this.ctx = nil }()
+
+	receipt, result, err := this.Run(job, blockContext, txContext)
+	if !this.evm.Cancelled() {
+		return receipt, result, err
+	}
+
+	// The EVM stops silently on cancellation, the result needs to be overwritten.
+	result.Err = ErrExecutionTimeout
+	receipt.Status = types.ReceiptStatusFailed
+	return receipt, result, err
+}
+
+// Get the assertion info from the execution result, see DecodeRevert() for the structured version.
 func GetAssertion(ret []byte) string {
-	offset := 4 + 32 + 32
-	pattern := []byte{8, 195, 121, 160}
-	if ret != nil && len(ret) > offset {
-		starts := ret[:4]
-		if string(pattern) == string(starts) {
-			remains := ret[offset:]
-			end := 0
-			for i := range remains {
-				if remains[i] == 0 {
-					end = i
-					break
-				}
-			}
-			return string(remains[:end])
-		}
+	if revert := DecodeRevert(ret); revert != nil && len(revert.Name) > 0 {
+		return revert.Reason
 	}
 	return ""
 }
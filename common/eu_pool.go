@@ -0,0 +1,89 @@
+/*
+ *   Copyright (c) 2025 Arcology Network
+
+ *   This program is free software: you can redistribute it and/or modify
+ *   it under the terms of the GNU General Public License as published by
+ *   the Free Software Foundation, either version 3 of the License, or
+ *   (at your option) any later version.
+
+ *   This program is distributed in the hope that it will be useful,
+ *   but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *   GNU General Public License for more details.
+
+ *   You should have received a copy of the GNU General Public License
+ *   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"github.com/arcology-network/common-lib/common"
+	eth "github.com/arcology-network/eu/eth"
+	intf "github.com/arcology-network/eu/interface"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// EUPool keeps the idle EUs for reuse, one for each worker. Every EU comes with its own statedb and API router.
+// For simple transfers, creating them costs more than the execution itself.
+type EUPool struct {
+	eus chan *EU
+}
+
+func NewEUPool(numWorkers int) *EUPool {
+	return &EUPool{
+		eus: make(chan *EU, common.Max(numWorkers, 1)),
+	}
+}
+
+// Get returns an EU working on a new cache layer on top of the parent router. A nil pool always creates a new one.
+func (this *EUPool) Get(config *Config, parent intf.EthApiRouter, msg *core.Message) *EU {
+	if this != nil && config.VMConfig.Tracer == nil { // Tracers are stateful, don't share them.
+		select {
+		case eu := <-this.eus:
+			api := parent.CascadeTo(eu.api)
+			statedb := eu.statedb.(*eth.ImplStateDB)
+			statedb.SetApi(api)
+
+			eu.SetRuntimeContext(statedb, api) // The statedb gets reset by PrepareFormer() in Run().
+			api.SetEU(eu)
+			return eu
+		default:
+		}
+	}
+	return newEU(config, parent.Cascade(), msg)
+}
+
+// Put returns the EU to the pool. The EU is dropped if the pool is full or its EVM has been cancelled,
+// a cancelled EVM can't run anything anymore.
+func (this *EUPool) Put(eu *EU) {
+	if this == nil || eu.evm.Cancelled() || eu.VmConfig.Tracer != nil {
+		return
+	}
+
+	select {
+	case this.eus <- eu:
+	default:
+	}
+}
+
+// newEU creates a new EU with a new statedb on top of the api router.
+func newEU(config *Config, api intf.EthApiRouter, msg *core.Message) *EU {
+	vmconfig := vm.Config{}
+	var txctx *vm.TxContext
+	if config.VMConfig.Tracer != nil {
+		vmconfig.Tracer = config.VMConfig.Tracer
+		vmconfig.NoBaseFee = config.VMConfig.NoBaseFee
+		ctx := core.NewEVMTxContext(msg)
+		txctx = &ctx
+	}
+
+	return NewEU(
+		config.ChainConfig,
+		vmconfig, //vm.Config{},
+		eth.NewImplStateDB(api),
+		api,
+		txctx,
+	)
+}
@@ -47,25 +47,7 @@ type ExecutionLogsMessage struct {
 	Msgid  uint64
 }
 
-func GetAssert(ret []byte) string {
-	startIdx := 4 + 32 + 32
-	pattern := []byte{8, 195, 121, 160}
-	if ret != nil || len(ret) > startIdx {
-		starts := ret[:4]
-		if string(pattern) == string(starts) {
-			remains := ret[startIdx:]
-			end := 0
-			for i := range remains {
-				if remains[i] == 0 {
-					end = i
-					break
-				}
-			}
-			return string(remains[:end])
-		}
-	}
-	return ""
-}
+func GetAssert(ret []byte) string { return GetAssertion(ret) }
 
 func NewExecutionLogs() *ExecutionLogs {
 	return &ExecutionLogs{
@@ -41,6 +41,9 @@ const (
 	GAS_NEW_CONTAINER  = int64(10000)
 	GAS_CONTAINER_META = int64(1000)
 
+	GAS_CUMULATIVE_U64_READ  = GAS_READ / 4        // 400 / 4 = 100, per counter, a commutative uint64 is 1/4 of the size of a U256.
+	GAS_CUMULATIVE_U64_WRITE = GAS_DELTA_WRITE / 4 // 10,000 / 4 = 2,500
+
 	DATA_UNIT_SIZE      = uint64(32)
 	DATA_MIN_READ_SIZE  = DATA_UNIT_SIZE
 	DATA_MIN_WRITE_SIZE = DATA_UNIT_SIZE
@@ -18,6 +18,7 @@
 package common
 
 import (
+	"context"
 	"crypto/sha256"
 
 	"github.com/arcology-network/common-lib/codec"
@@ -25,7 +26,6 @@ import (
 	mapi "github.com/arcology-network/common-lib/exp/map"
 	slice "github.com/arcology-network/common-lib/exp/slice"
 	commontype "github.com/arcology-network/common-lib/types"
-	eth "github.com/arcology-network/eu/eth"
 	intf "github.com/arcology-network/eu/interface"
 	stgcommon "github.com/arcology-network/storage-committer/common"
 	cache "github.com/arcology-network/storage-committer/storage/cache"
@@ -33,9 +33,8 @@ import (
 	univalue "github.com/arcology-network/storage-committer/type/univalue"
 
 	evmcommon "github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core"
 	evmcore "github.com/ethereum/go-ethereum/core"
-	"github.com/ethereum/go-ethereum/core/vm"
+	ethcoretypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/holiman/uint256"
 )
 
@@ -49,30 +48,24 @@ type Job struct {
 	PrepaidGas   uint64  // Gas paid for the deferred execution, negative is paying for the others, positive is paied by others.
 }
 
-// Execute executes the job.
-func (this *Job) execute(StdMsg *commontype.StandardMessage, config *Config, api intf.EthApiRouter) {
+// Execute executes the job. The job is skipped if the context is already done when it starts, it gets a failed
+// receipt using no gas.
+func (this *Job) execute(ctx context.Context, StdMsg *commontype.StandardMessage, config *Config, eu *EU) {
 	this.StdMsg = StdMsg
-	statedb := eth.NewImplStateDB(api)
-	statedb.PrepareFormer(this.StdMsg.TxHash, [32]byte{}, uint64(this.StdMsg.ID))
-	vmconfig := vm.Config{}
-	var txctx *vm.TxContext
-	if config.VMConfig.Tracer != nil {
-		vmconfig.Tracer = config.VMConfig.Tracer
-		vmconfig.NoBaseFee = config.VMConfig.NoBaseFee
-		ctx := core.NewEVMTxContext(StdMsg.Native)
-		txctx = &ctx
+	if ctx.Err() != nil {
+		this.fail(config, ErrExecutionTimeout)
+		return
 	}
 
-	eu := NewEU(
-		config.ChainConfig,
-		vmconfig, //vm.Config{},
-		statedb,
-		api,
-		txctx,
-	)
+	if config.JobTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.JobTimeout)
+		defer cancel()
+	}
 
 	receipt, evmResult, prechkErr :=
-		eu.Run(
+		eu.RunWithContext(
+			ctx,
 			this,
 			NewEVMBlockContext(config),
 			NewEVMTxContext(*this.StdMsg.Native),
@@ -81,16 +74,35 @@ func (this *Job) execute(StdMsg *commontype.StandardMessage, config *Config, api
 	this.Results = (&Result{
 		TxIndex:          uint64(this.StdMsg.ID),
 		TxHash:           common.IfThenDo1st(receipt != nil, func() evmcommon.Hash { return receipt.TxHash }, evmcommon.Hash{}),
-		RawStateAccesses: cache.NewWriteCacheFilter(api.WriteCache()).ToBuffer(),
+		RawStateAccesses: cache.NewWriteCacheFilter(eu.Api().WriteCache()).ToBuffer(),
 		Err:              common.IfThenDo1st(prechkErr == nil, func() error { return evmResult.Err }, prechkErr),
+		PrecheckErr:      prechkErr,
 		From:             this.StdMsg.Native.From,
 		Coinbase:         *config.Coinbase,
 		Receipt:          receipt,
 		EvmResult:        evmResult,
+		Revert:           DecodeRevert(evmResult.Revert()),
 		StdMsg:           this.StdMsg,
 	}).Postprocess()
 }
 
+// fail gives the job a failed receipt using no gas without executing it, the error goes into the results.
+func (this *Job) fail(config *Config, err error) {
+	receipt := ethcoretypes.NewReceipt(nil, true, 0)
+	receipt.TxHash = this.StdMsg.TxHash
+
+	this.Results = &Result{
+		TxIndex:   uint64(this.StdMsg.ID),
+		TxHash:    this.StdMsg.TxHash,
+		Err:       err,
+		From:      this.StdMsg.Native.From,
+		Coinbase:  *config.Coinbase,
+		Receipt:   receipt,
+		EvmResult: &evmcore.ExecutionResult{Err: err},
+		StdMsg:    this.StdMsg,
+	}
+}
+
 func (this *Job) Successful() bool {
 	if this.Results != nil {
 		return this.Results.Receipt != nil &&
@@ -173,6 +185,12 @@ func (this *JobSequence) Length() int { return len(this.Jobs) }
 // Run executes the job sequence and returns the results. nonceOffset is used to calculate the nonce of the transaction, in
 // case there is a contract deployment in the sequence.
 func (this *JobSequence) Run(config *Config, seqAPI intf.EthApiRouter, threadId uint64) ([]uint64, []*univalue.Univalue) {
+	return this.RunWithContext(context.Background(), config, seqAPI, nil, threadId)
+}
+
+// RunWithContext executes the job sequence like Run. Once the context is done, the running job is cancelled and
+// the jobs left are skipped. They are all marked with ErrExecutionTimeout. The EUs are reused from the pool if there is one.
+func (this *JobSequence) RunWithContext(ctx context.Context, config *Config, seqAPI intf.EthApiRouter, pool *EUPool, threadId uint64) ([]uint64, []*univalue.Univalue) {
 	this.SeqAPI = seqAPI //.Cascade() // Create a new write cache for the sequence with the main router as the data source.
 	this.SeqAPI.DecrementDepth()
 
@@ -180,21 +198,25 @@ func (this *JobSequence) Run(config *Config, seqAPI intf.EthApiRouter, threadId
 	// this.Results = make([]*Result, len(this.StdMsgs))
 	if len(this.Jobs) == 1 {
 		// this.Results[0] = this.execute(this.StdMsgs[0], config, this.SeqAPI.Cascade())
-		this.Jobs[0].execute(this.Jobs[0].StdMsg, config, this.SeqAPI.Cascade())
+		eu := pool.Get(config, this.SeqAPI, this.Jobs[0].StdMsg.Native)
+		this.Jobs[0].execute(ctx, this.Jobs[0].StdMsg, config, eu)
+		pool.Put(eu)
 		// this.Jobs[0].Results = this.execute(this.Jobs[0].StdMsg, config, this.SeqAPI.Cascade())
 		return slice.Fill(make([]uint64, len(this.Jobs[0].Results.RawStateAccesses)), this.ID), this.Jobs[0].Results.RawStateAccesses
 	}
 
 	for i, job := range this.Jobs {
-		txApi := this.SeqAPI.Cascade() // A new router whose writeCache uses the parent APIHandler's writeCache as the data source.
-		txApi.DecrementDepth()         // The api router always increments the depth.  So we need to decrement it here.
+		eu := pool.Get(config, this.SeqAPI, job.StdMsg.Native) // A new router whose writeCache uses the parent APIHandler's writeCache as the data source.
+		txApi := eu.Api()
+		txApi.DecrementDepth() // The api router always increments the depth.  So we need to decrement it here.
 
 		// this.Jobs[i].Results = this.execute(job.StdMsg, config, txApi) // Execute the message and store the result.
-		this.Jobs[i].execute(job.StdMsg, config, txApi) // Execute the message and store the result.
+		this.Jobs[i].execute(ctx, job.StdMsg, config, eu) // Execute the message and store the result.
 
 		// the line below modifies the cache in the major api as well.
 		this.SeqAPI.WriteCache().(*cache.WriteCache).Insert(this.Jobs[i].Results.RawStateAccesses) // Merge the txApi write cache back into the api router.
 		mapi.Merge(txApi.AuxDict(), this.SeqAPI.AuxDict())                                         // The tx may generate new aux data, so merge it into the main api router.
+		pool.Put(eu)
 	}
 
 	// Get acumulated state access records from all the transactions in the sequence.
@@ -202,6 +224,13 @@ func (this *JobSequence) Run(config *Config, seqAPI intf.EthApiRouter, threadId
 	return slice.Fill(make([]uint64, len(accmulatedAccessRecords)), this.ID), accmulatedAccessRecords
 }
 
+// Fail marks all the jobs in the sequence as failed with the error without executing them, see Job.fail().
+func (this *JobSequence) Fail(config *Config, err error) {
+	for _, job := range this.Jobs {
+		job.fail(config, err)
+	}
+}
+
 // GetClearedTransition returns the cleared transitions of the JobSequence.
 func (this *JobSequence) GetClearedTransition() []*univalue.Univalue {
 	// if idx, _ := slice.FindFirstIf(this.Results, func(v *Result) bool { return v.Err != nil }); idx < 0 {
@@ -261,7 +290,7 @@ func (this *JobSequence) FlagConflict(dict map[uint64]uint64, err error) {
 // 	return (&Result{
 // 		TxIndex:          uint64(StdMsg.ID),
 // 		TxHash:           common.IfThenDo1st(receipt != nil, func() evmcommon.Hash { return receipt.TxHash }, evmcommon.Hash{}),
-// 		RawStateAccesses: cache.NewWriteCacheFilter(api.WriteCache()).ToBuffer(),
+// 		RawStateAccesses: cache.NewWriteCacheFilter(eu.Api().WriteCache()).ToBuffer(),
 // 		Err:              common.IfThenDo1st(prechkErr == nil, func() error { return evmResult.Err }, prechkErr),
 // 		From:             StdMsg.Native.From,
 // 		Coinbase:         *config.Coinbase,
@@ -44,8 +44,10 @@ type Result struct {
 	Immuned          []*univalue.Univalue //These transitions will take effect anyway even if the execution fails.
 	Receipt          *ethcoretypes.Receipt
 	EvmResult        *evmcore.ExecutionResult
+	Revert           *Revert // The decoded revert reason, nil if the execution didn't revert.
 	StdMsg           *commontype.StandardMessage
 	Err              error
+	PrecheckErr      error // The error from the prechecks, the transaction never reached the EVM.
 }
 
 // The tx sender has to pay the tx fees regardless the execution status. This function deducts the gas fee from the sender's balance
@@ -0,0 +1,136 @@
+/*
+ *   Copyright (c) 2025 Arcology Network
+
+ *   This program is free software: you can redistribute it and/or modify
+ *   it under the terms of the GNU General Public License as published by
+ *   the Free Software Foundation, either version 3 of the License, or
+ *   (at your option) any later version.
+
+ *   This program is distributed in the hope that it will be useful,
+ *   but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *   GNU General Public License for more details.
+
+ *   You should have received a copy of the GNU General Public License
+ *   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	errorSelector = [4]byte(crypto.Keccak256([]byte("Error(string)"))[:4])  // 0x08c379a0
+	panicSelector = [4]byte(crypto.Keccak256([]byte("Panic(uint256)"))[:4]) // 0x4e487b71
+
+	stringArgs, _  = abi.NewType("string", "", nil)
+	uint256Args, _ = abi.NewType("uint256", "", nil)
+
+	// The standard panic codes of Solidity, the same as in ethers.js.
+	panicReasons = map[uint64]string{
+		0x00: "generic panic",
+		0x01: "assert(false)",
+		0x11: "arithmetic underflow or overflow",
+		0x12: "division or modulo by zero",
+		0x21: "enum overflow",
+		0x22: "invalid encoded storage byte array accessed",
+		0x31: "out-of-bounds array access; popping on an empty array",
+		0x32: "out-of-bounds access of an array or bytesN",
+		0x41: "out of memory",
+		0x51: "uninitialized function",
+	}
+
+	// The custom errors registered by RegisterErrorABI(), indexed by their selectors.
+	customErrors     = map[[4]byte]abi.Error{}
+	customErrorsLock sync.RWMutex
+)
+
+// Revert is the decoded revert data of a failed execution.
+type Revert struct {
+	Selector hexutil.Bytes `json:"selector"`
+	Name     string        `json:"name"`   // Error, Panic or the custom error name, empty if the error is unknown.
+	Args     []any         `json:"args"`   // The decoded arguments.
+	Reason   string        `json:"reason"` // Human readable reason.
+	Data     hexutil.Bytes `json:"data"`   // The raw data after the selector.
+}
+
+// RegisterErrorABI registers all the custom errors in an ABI definition, so they can be decoded by name and arguments.
+func RegisterErrorABI(definition string) error {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		return err
+	}
+
+	customErrorsLock.Lock()
+	defer customErrorsLock.Unlock()
+	for _, customErr := range parsed.Errors {
+		customErrors[[4]byte(customErr.ID[:4])] = customErr
+	}
+	return nil
+}
+
+// DecodeRevert decodes the revert data of Error(string), Panic(uint256) and the registered custom errors. The unknown
+// errors only have their selectors and raw data. It returns nil if the data is too short to have a selector.
+func DecodeRevert(ret []byte) *Revert {
+	if len(ret) < 4 {
+		return nil
+	}
+
+	revert := &Revert{
+		Selector: hexutil.Bytes(ret[:4]),
+		Data:     hexutil.Bytes(ret[4:]),
+	}
+
+	switch selector := [4]byte(ret[:4]); selector {
+	case errorSelector:
+		if args, err := (abi.Arguments{{Type: stringArgs}}).Unpack(ret[4:]); err == nil {
+			revert.Name, revert.Args, revert.Reason = "Error", args, args[0].(string)
+		}
+
+	case panicSelector:
+		if args, err := (abi.Arguments{{Type: uint256Args}}).Unpack(ret[4:]); err == nil {
+			code := args[0].(*big.Int)
+			reason, ok := panicReasons[code.Uint64()]
+			if !ok || !code.IsUint64() {
+				reason = "unknown panic code"
+			}
+			revert.Name, revert.Args, revert.Reason = "Panic", args, fmt.Sprintf("%s (0x%x)", reason, code)
+		}
+
+	default:
+		customErrorsLock.RLock()
+		customErr, ok := customErrors[selector]
+		customErrorsLock.RUnlock()
+
+		if ok {
+			if args, err := customErr.Inputs.Unpack(ret[4:]); err == nil {
+				formatted := make([]string, len(args))
+				for i, arg := range args {
+					formatted[i] = fmt.Sprint(arg)
+				}
+				revert.Name, revert.Args, revert.Reason = customErr.Name, args, customErr.Name+"("+strings.Join(formatted, ", ")+")"
+			}
+		}
+	}
+
+	if len(revert.Name) == 0 {
+		revert.Reason = fmt.Sprintf("unknown error %v", revert.Selector)
+	}
+	return revert
+}
+
+// String returns the revert in JSON, which is what goes into the execution log.
+func (this *Revert) String() string {
+	buffer, _ := json.Marshal(this)
+	return string(buffer)
+}
@@ -0,0 +1,70 @@
+/*
+ *   Copyright (c) 2025 Arcology Network
+
+ *   This program is free software: you can redistribute it and/or modify
+ *   it under the terms of the GNU General Public License as published by
+ *   the Free Software Foundation, either version 3 of the License, or
+ *   (at your option) any later version.
+
+ *   This program is distributed in the hope that it will be useful,
+ *   but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *   GNU General Public License for more details.
+
+ *   You should have received a copy of the GNU General Public License
+ *   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	evmcommon "github.com/ethereum/go-ethereum/common"
+)
+
+func TestDecodeRevert(t *testing.T) {
+	// The message has a zero byte in the middle, it must not be cut there.
+	ret, _ := (abi.Arguments{{Type: stringArgs}}).Pack("not\x00enough")
+	if revert := DecodeRevert(append(errorSelector[:], ret...)); revert.Name != "Error" || revert.Reason != "not\x00enough" {
+		t.Error("Wrong Error(string)", revert)
+	}
+
+	ret, _ = (abi.Arguments{{Type: uint256Args}}).Pack(big.NewInt(0x11))
+	if revert := DecodeRevert(append(panicSelector[:], ret...)); revert.Name != "Panic" || revert.Reason != "arithmetic underflow or overflow (0x11)" {
+		t.Error("Wrong Panic(uint256)", revert)
+	}
+
+	if revert := DecodeRevert([]byte{1, 2, 3}); revert != nil {
+		t.Error("Expected nil for the data without a selector")
+	}
+}
+
+func TestDecodeCustomError(t *testing.T) {
+	definition := `[{"type":"error","name":"InsufficientBalance","inputs":[{"name":"account","type":"address"},{"name":"needed","type":"uint256"}]}]`
+	parsed, _ := abi.JSON(strings.NewReader(definition))
+	customErr := parsed.Errors["InsufficientBalance"]
+	ret, _ := customErr.Inputs.Pack(evmcommon.Address{1}, big.NewInt(100))
+	ret = append(customErr.ID[:4:4], ret...)
+
+	if revert := DecodeRevert(ret); revert.Name != "" || revert.Reason != "unknown error 0x"+hex.EncodeToString(ret[:4]) {
+		t.Error("The error isn't registered yet", revert)
+	}
+
+	if err := RegisterErrorABI(definition); err != nil {
+		t.Fatal(err)
+	}
+
+	revert := DecodeRevert(ret)
+	if revert.Name != "InsufficientBalance" || len(revert.Args) != 2 || revert.Args[0].(evmcommon.Address) != (evmcommon.Address{1}) {
+		t.Error("Wrong custom error", revert)
+	}
+
+	if revert.Reason != "InsufficientBalance(0x0100000000000000000000000000000000000000, 100)" {
+		t.Error("Wrong reason", revert.Reason)
+	}
+}
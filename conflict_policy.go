@@ -0,0 +1,94 @@
+/*
+ *   Copyright (c) 2025 Arcology Network
+
+ *   This program is free software: you can redistribute it and/or modify
+ *   it under the terms of the GNU General Public License as published by
+ *   the Free Software Foundation, either version 3 of the License, or
+ *   (at your option) any later version.
+
+ *   This program is distributed in the hope that it will be useful,
+ *   but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *   GNU General Public License for more details.
+
+ *   You should have received a copy of the GNU General Public License
+ *   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package execution
+
+import (
+	"errors"
+	"math/big"
+
+	eucommon "github.com/arcology-network/eu/common"
+)
+
+// ErrDuplicateTxID is the error for the sequences rejected by a conflict policy, because some of their transactions
+// share the same ID.
+var ErrDuplicateTxID = errors.New("Error: Duplicate transaction IDs in the sequence")
+
+// ConflictPolicy decides which job sequence wins when two sequences are in conflict. The arbitrator
+// always keeps the sequence ranked first by the policy and flags the others.
+type ConflictPolicy interface {
+	Less(lhv, rhv *eucommon.JobSequence) bool // Returns true if lhv should win over rhv.
+}
+
+// LowestSeqIDWins keeps the sequence with the lowest sequence ID.
+type LowestSeqIDWins struct{}
+
+func (LowestSeqIDWins) Less(lhv, rhv *eucommon.JobSequence) bool { return lhv.ID < rhv.ID }
+
+// FirstInBlockWins keeps the sequence whose first transaction comes first in the block.
+type FirstInBlockWins struct{}
+
+func (FirstInBlockWins) Less(lhv, rhv *eucommon.JobSequence) bool {
+	if lhvID, rhvID := firstTxID(lhv), firstTxID(rhv); lhvID != rhvID {
+		return lhvID < rhvID
+	}
+	return lhv.ID < rhv.ID
+}
+
+// HighestGasPriceWins keeps the sequence whose first transaction pays the highest gas price.
+type HighestGasPriceWins struct{}
+
+func (HighestGasPriceWins) Less(lhv, rhv *eucommon.JobSequence) bool {
+	if diff := gasPrice(lhv).Cmp(gasPrice(rhv)); diff != 0 {
+		return diff > 0
+	}
+	return lhv.ID < rhv.ID
+}
+
+// FewestAccessesWins keeps the sequence that accessed the fewest states, which is the cheapest one to re-execute.
+type FewestAccessesWins struct{}
+
+func (FewestAccessesWins) Less(lhv, rhv *eucommon.JobSequence) bool {
+	if lhvNum, rhvNum := numAccesses(lhv), numAccesses(rhv); lhvNum != rhvNum {
+		return lhvNum < rhvNum
+	}
+	return lhv.ID < rhv.ID
+}
+
+func firstTxID(seq *eucommon.JobSequence) uint64 {
+	if len(seq.Jobs) == 0 || seq.Jobs[0].StdMsg == nil {
+		return seq.ID
+	}
+	return seq.Jobs[0].StdMsg.ID
+}
+
+func gasPrice(seq *eucommon.JobSequence) *big.Int {
+	if len(seq.Jobs) == 0 || seq.Jobs[0].StdMsg == nil || seq.Jobs[0].StdMsg.Native == nil || seq.Jobs[0].StdMsg.Native.GasPrice == nil {
+		return new(big.Int)
+	}
+	return seq.Jobs[0].StdMsg.Native.GasPrice
+}
+
+func numAccesses(seq *eucommon.JobSequence) int {
+	total := 0
+	for _, job := range seq.Jobs {
+		if job.Results != nil {
+			total += len(job.Results.RawStateAccesses)
+		}
+	}
+	return total
+}
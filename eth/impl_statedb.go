@@ -18,6 +18,10 @@
 package eth
 
 import (
+	"slices"
+	"strings"
+
+	"github.com/arcology-network/common-lib/exp/softdeltaset"
 	intf "github.com/arcology-network/eu/interface"
 	cache "github.com/arcology-network/storage-committer/storage/cache"
 	commutative "github.com/arcology-network/storage-committer/type/commutative"
@@ -38,6 +42,10 @@ type ImplStateDB struct {
 	tid              uint64 // tx id
 	logs             map[evmcommon.Hash][]*evmtypes.Log
 	transientStorage transientStorage
+	journal          *journal                       // State changes since the beginning of the transaction, for the snapshots.
+	accessList       *accessList                    // EIP-2929 warm addresses and slots of the transaction.
+	created          map[evmcommon.Address]struct{} // Accounts created by CreateAccount in the transaction.
+	destructed       []evmcommon.Address            // Accounts to remove at the end of the transaction, see Finalise().
 	api              intf.EthApiRouter
 }
 
@@ -46,10 +54,29 @@ func NewImplStateDB(api intf.EthApiRouter) *ImplStateDB {
 		logs:             make(map[evmcommon.Hash][]*evmtypes.Log),
 		api:              api,
 		transientStorage: newTransientStorage(),
+		journal:          newJournal(),
+		accessList:       newAccessList(),
+		created:          map[evmcommon.Address]struct{}{},
+		destructed:       []evmcommon.Address{},
 	}
 }
 
+// CreateAccount is called by the EVM when creating a contract. The account is marked as created in the transaction,
+// so it can be destructed by SELFDESTRUCT under EIP-6780.
 func (this *ImplStateDB) CreateAccount(addr evmcommon.Address) {
+	this.newAccount(addr)
+	if _, ok := this.created[addr]; !ok {
+		this.journal.append(createContractChange{account: addr})
+		this.created[addr] = struct{}{}
+	}
+}
+
+// newAccount creates the account paths without marking the account as created in the transaction, for the accounts
+// implicitly created by the balance transfers and the state writes.
+func (this *ImplStateDB) newAccount(addr evmcommon.Address) {
+	if !this.Exist(addr) {
+		this.journal.append(createAccountChange{account: addr})
+	}
 	createAccount(this.api.WriteCache().(*cache.WriteCache), addr, this.tid)
 }
 
@@ -65,13 +92,19 @@ func (this *ImplStateDB) SubBalance(addr evmcommon.Address, amount *uint256.Int)
 // Its Ethereum counterpart is in the (s *stateObject) setBalance(amount *uint256.Int) function.
 func (this *ImplStateDB) updateBalance(addr evmcommon.Address, amount *uint256.Int, isPositive bool) {
 	if !this.Exist(addr) {
-		createAccount(this.api.WriteCache().(*cache.WriteCache), addr, this.tid)
+		this.newAccount(addr)
 	}
 
 	if amount.IsZero() {
 		return
 	}
 
+	this.journal.append(balanceChange{account: addr, amount: amount.Clone(), isPositive: isPositive})
+	this.writeBalanceDelta(addr, amount, isPositive)
+}
+
+// writeBalanceDelta writes the balance change into the write cache without journaling it.
+func (this *ImplStateDB) writeBalanceDelta(addr evmcommon.Address, amount *uint256.Int, isPositive bool) {
 	delta := commutative.NewU256Delta(amount, isPositive) // Create a delta
 	if _, err := this.api.WriteCache().(*cache.WriteCache).Write(this.tid, getBalancePath(this.api.WriteCache().(*cache.WriteCache), addr), delta); err != nil {
 		panic("Error: Failed to updateBalance() with delta")
@@ -116,7 +149,7 @@ func (this *ImplStateDB) GetNonce(addr evmcommon.Address) uint64 {
 
 func (this *ImplStateDB) SetNonce(addr evmcommon.Address, nonce uint64) {
 	if !this.Exist(addr) {
-		createAccount(this.api.WriteCache().(*cache.WriteCache), addr, this.tid)
+		this.newAccount(addr)
 	}
 	// fmt.Println("SetNonce:", addr, ":", nonce)
 
@@ -125,6 +158,7 @@ func (this *ImplStateDB) SetNonce(addr evmcommon.Address, nonce uint64) {
 	if _, err := this.api.WriteCache().(*cache.WriteCache).Write(this.tid, getNoncePath(this.api.WriteCache().(*cache.WriteCache), addr), commutative.NewUint64Delta(1)); err != nil {
 		panic(err)
 	}
+	this.journal.append(nonceChange{account: addr})
 }
 
 func (this *ImplStateDB) GetCodeHash(addr evmcommon.Address) evmcommon.Hash {
@@ -151,27 +185,60 @@ func (this *ImplStateDB) GetCode(addr evmcommon.Address) []byte {
 
 func (this *ImplStateDB) SetCode(addr evmcommon.Address, code []byte) {
 	if !this.Exist(addr) {
-		createAccount(this.api.WriteCache().(*cache.WriteCache), addr, this.tid)
+		this.newAccount(addr)
 	}
 
-	if _, err := this.api.WriteCache().(*cache.WriteCache).Write(this.tid, getCodePath(this.api.WriteCache().(*cache.WriteCache), addr), noncommutative.NewBytes(code)); err != nil {
+	path := getCodePath(this.api.WriteCache().(*cache.WriteCache), addr)
+	prev, existed := this.peekBytes(path)
+	this.journal.append(codeChange{account: addr, prev: prev, existed: existed})
+
+	if _, err := this.api.WriteCache().(*cache.WriteCache).Write(this.tid, path, noncommutative.NewBytes(code)); err != nil {
 		panic(err)
 	}
 }
 
-func (this *ImplStateDB) SelfDestruct(addr evmcommon.Address)           {}
-func (this *ImplStateDB) HasSelfDestructed(addr evmcommon.Address) bool { return false }
-func (this *ImplStateDB) Selfdestruct6780(common.Address)               {}
+// SelfDestruct is called by the pre-Cancun SELFDESTRUCT after crediting the beneficiary with the balance. The same as
+// in geth, the account is destructed whenever it was created and its balance is cleared, as a commutative delta. When
+// the account is its own beneficiary, the balance is burnt.
+func (this *ImplStateDB) SelfDestruct(addr evmcommon.Address) {
+	if !this.Exist(addr) {
+		return
+	}
+
+	this.SubBalance(addr, this.GetBalance(addr))
+	if !this.HasSelfDestructed(addr) {
+		this.journal.append(selfDestructChange{account: addr})
+		this.destructed = append(this.destructed, addr)
+	}
+}
 
-func (this *ImplStateDB) GetCodeSize(addr evmcommon.Address) int                          { return len(this.GetCode(addr)) }
-func (this *ImplStateDB) AddRefund(amount uint64)                                         { this.refund += amount }
-func (this *ImplStateDB) SubRefund(amount uint64)                                         { this.refund -= amount }
-func (this *ImplStateDB) GetRefund() uint64                                               { return this.refund }
-func (this *ImplStateDB) RevertToSnapshot(id int)                                         {}
-func (this *ImplStateDB) Snapshot() int                                                   { return 0 }
-func (this *ImplStateDB) AddPreimage(hash evmcommon.Hash, preimage []byte)                {}
-func (this *ImplStateDB) AddAddressToAccessList(addr evmcommon.Address)                   {} // Do nothing.
-func (this *ImplStateDB) AddSlotToAccessList(addr evmcommon.Address, slot evmcommon.Hash) {}
+// Selfdestruct6780 is called by the Cancun SELFDESTRUCT after moving the balance to the beneficiary. Only the accounts
+// created in the same transaction are destructed, the others are kept with the balance they have left, see EIP-6780.
+// The paths of the destructed accounts are removed in Finalise() at the end of the transaction.
+func (this *ImplStateDB) Selfdestruct6780(addr evmcommon.Address) {
+	if _, ok := this.created[addr]; ok {
+		this.SelfDestruct(addr)
+	}
+}
+
+func (this *ImplStateDB) HasSelfDestructed(addr evmcommon.Address) bool {
+	return slices.Contains(this.destructed, addr)
+}
+
+// Finalise removes all the paths of the destructed accounts from the write cache. It is called once the transaction
+// is done, since the code of a destructed contract still works until then.
+func (this *ImplStateDB) Finalise() {
+	for _, addr := range this.destructed {
+		this.deletePath(getAccountRootPath(nil, addr))
+	}
+	this.destructed = this.destructed[:0]
+}
+
+func (this *ImplStateDB) GetCodeSize(addr evmcommon.Address) int           { return len(this.GetCode(addr)) }
+func (this *ImplStateDB) GetRefund() uint64                                { return this.refund }
+func (this *ImplStateDB) RevertToSnapshot(id int)                          { this.journal.revertToSnapshot(id, this) }
+func (this *ImplStateDB) Snapshot() int                                    { return this.journal.snapshot() }
+func (this *ImplStateDB) AddPreimage(hash evmcommon.Hash, preimage []byte) {}
 
 // func (this *ImplStateDB) Set(eac EthAccountCache, esc EthStorageCache)                    {} // TODO
 
@@ -194,15 +261,55 @@ func (this *ImplStateDB) GetState(addr evmcommon.Address, key evmcommon.Hash) ev
 
 func (this *ImplStateDB) SetState(addr evmcommon.Address, key, value evmcommon.Hash) {
 	if !this.Exist(addr) {
-		createAccount(this.api.WriteCache().(*cache.WriteCache), addr, this.tid)
+		this.newAccount(addr)
 	}
 
 	path := getStorageKeyPath(this.api, addr, key)
+	prev, existed := this.peekBytes(path)
+	this.journal.append(storageChange{account: addr, key: key, prev: prev, existed: existed})
+
 	if _, err := this.api.WriteCache().(*cache.WriteCache).Write(this.tid, path, noncommutative.NewBytes(value.Bytes())); err != nil {
 		panic(err)
 	}
 }
 
+func (this *ImplStateDB) AddRefund(amount uint64) {
+	this.journal.append(refundChange{prev: this.refund})
+	this.refund += amount
+}
+
+func (this *ImplStateDB) SubRefund(amount uint64) {
+	this.journal.append(refundChange{prev: this.refund})
+	this.refund -= amount
+}
+
+// peekBytes gets the current value of the path without leaving a read access.
+func (this *ImplStateDB) peekBytes(path string) ([]byte, bool) {
+	if value, _, _ := this.api.WriteCache().(*cache.WriteCache).Peek(path, new(noncommutative.Bytes)); value != nil {
+		return value.([]byte), true
+	}
+	return nil, false
+}
+
+// restoreBytes writes the previous value back to the path or removes it if it didn't exist.
+func (this *ImplStateDB) restoreBytes(path string, prev []byte, existed bool) {
+	if !existed {
+		this.deletePath(path)
+		return
+	}
+
+	if _, err := this.api.WriteCache().(*cache.WriteCache).Write(this.tid, path, noncommutative.NewBytes(prev)); err != nil {
+		panic(err)
+	}
+}
+
+// deletePath removes the path along with all its sub paths.
+func (this *ImplStateDB) deletePath(path string) {
+	if _, err := this.api.WriteCache().(*cache.WriteCache).Write(this.tid, path, nil); err != nil {
+		panic(err)
+	}
+}
+
 func (this *ImplStateDB) Exist(addr evmcommon.Address) bool {
 	flag := accountExist(this.api.WriteCache().(*cache.WriteCache), addr, this.tid)
 	// fmt.Println(addr, flag)
@@ -221,6 +328,7 @@ func (s *ImplStateDB) SetTransientState(addr evmcommon.Address, key, value evmco
 	if prev == value {
 		return
 	}
+	s.journal.append(transientStorageChange{account: addr, key: key, prev: prev})
 	s.setTransientState(addr, key, value)
 }
 
@@ -234,26 +342,101 @@ func (s *ImplStateDB) setTransientState(addr evmcommon.Address, key, value evmco
 func (s *ImplStateDB) GetTransientState(addr evmcommon.Address, key evmcommon.Hash) evmcommon.Hash {
 	return s.transientStorage.Get(addr, key)
 }
+
+// Prepare seeds the access list of the transaction and clears the transient storage. Unlike geth, the access list is
+// seeded regardless of the rules, because the interpreter of the EU always runs with the EIP-2929 gas table.
 func (this *ImplStateDB) Prepare(rules params.Rules, sender, coinbase common.Address, dest *common.Address, precompiles []common.Address, txAccesses types.AccessList) {
-	//Do nothing
+	this.PrepareAccessList(sender, dest, precompiles, txAccesses)
+	if rules.IsShanghai { // EIP-3651: warm coinbase
+		this.accessList.AddAddress(coinbase)
+	}
+	this.transientStorage = newTransientStorage()
 }
 
 func (this *ImplStateDB) AddLog(log *evmtypes.Log) {
+	this.journal.append(addLogChange{txhash: this.txHash})
 	this.logs[this.txHash] = append(this.logs[this.txHash], log)
 }
 
+// ForEachStorage visits the storage slots of the account, both the committed ones and the ones written in the
+// transaction, until the callback returns false. The storage path and the slots are all read through the write
+// cache, so the accesses are recorded for the conflict detection.
 func (this *ImplStateDB) ForEachStorage(addr evmcommon.Address, f func(evmcommon.Hash, evmcommon.Hash) bool) error {
+	writeCache := this.api.WriteCache().(*cache.WriteCache)
+	keys, _, _ := writeCache.Read(this.tid, getStorageRootPath(writeCache, addr), new(commutative.Path))
+	if keys == nil {
+		return nil
+	}
+
+	for _, key := range keys.(*softdeltaset.DeltaSet[string]).Elements() {
+		if len(key) != 2*evmcommon.HashLength+2 || strings.HasSuffix(key, "/") {
+			continue // A sub path like the local storage, not a slot.
+		}
+
+		slot := evmcommon.HexToHash(key)
+		if !f(slot, this.GetState(addr, slot)) {
+			break
+		}
+	}
 	return nil
 }
 
+// PrepareAccessList creates a new access list with the sender, the recipient, the precompiles and the entries
+// of the transaction access list. The Arcology API handlers are warm too, they work like the precompiles.
 func (this *ImplStateDB) PrepareAccessList(sender evmcommon.Address, dest *evmcommon.Address, precompiles []evmcommon.Address, txAccesses evmtypes.AccessList) {
-	// Do nothing.
+	this.accessList = newAccessList()
+	this.accessList.AddAddress(sender)
+	if dest != nil {
+		this.accessList.AddAddress(*dest) // If it's a create-tx, the destination will be added inside evm.create
+	}
+
+	for _, addr := range precompiles {
+		this.accessList.AddAddress(addr)
+	}
+
+	if router, ok := this.api.(interface {
+		HandlerDict() map[[20]byte]intf.ApiCallHandler
+	}); ok {
+		for addr := range router.HandlerDict() {
+			this.accessList.AddAddress(addr)
+		}
+	}
+
+	for _, el := range txAccesses {
+		this.accessList.AddAddress(el.Address)
+		for _, key := range el.StorageKeys {
+			this.accessList.AddSlot(el.Address, key)
+		}
+	}
+}
+
+func (this *ImplStateDB) AddAddressToAccessList(addr evmcommon.Address) {
+	if this.accessList.AddAddress(addr) {
+		this.journal.append(accessListAddAccountChange{address: addr})
+	}
+}
+
+func (this *ImplStateDB) AddSlotToAccessList(addr evmcommon.Address, slot evmcommon.Hash) {
+	addrMod, slotMod := this.accessList.AddSlot(addr, slot)
+	if addrMod {
+		// In practice, this should not happen, since there is no way to enter the
+		// scope of 'address' without having the 'address' become already added
+		// to the access list (via call-variant, create, etc).
+		// Better safe than sorry, though
+		this.journal.append(accessListAddAccountChange{address: addr})
+	}
+
+	if slotMod {
+		this.journal.append(accessListAddSlotChange{address: addr, slot: slot})
+	}
 }
 
-func (this *ImplStateDB) AddressInAccessList(addr evmcommon.Address) bool { return true }
+func (this *ImplStateDB) AddressInAccessList(addr evmcommon.Address) bool {
+	return this.accessList.ContainsAddress(addr)
+}
 
 func (this *ImplStateDB) SlotInAccessList(addr evmcommon.Address, slot evmcommon.Hash) (addressOk bool, slotOk bool) {
-	return true, true
+	return this.accessList.Contains(addr, slot)
 }
 
 func (this *ImplStateDB) PrepareFormer(txHash, bhash evmcommon.Hash, ti uint64) {
@@ -261,8 +444,16 @@ func (this *ImplStateDB) PrepareFormer(txHash, bhash evmcommon.Hash, ti uint64)
 	this.txHash = txHash
 	this.tid = ti
 	this.logs = make(map[evmcommon.Hash][]*evmtypes.Log)
+	this.transientStorage = newTransientStorage() // Transient storage only lives in one transaction.
+	this.journal.reset()
+	this.accessList = newAccessList()
+	this.created = map[evmcommon.Address]struct{}{}
+	this.destructed = this.destructed[:0]
 }
 
+func (this *ImplStateDB) Api() intf.EthApiRouter       { return this.api }
+func (this *ImplStateDB) SetApi(api intf.EthApiRouter) { this.api = api }
+
 func (this *ImplStateDB) GetLogs(hash evmcommon.Hash) []*evmtypes.Log {
 	return this.logs[hash]
 }
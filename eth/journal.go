@@ -0,0 +1,184 @@
+/*
+ *   Copyright (c) 2025 Arcology Network
+
+ *   This program is free software: you can redistribute it and/or modify
+ *   it under the terms of the GNU General Public License as published by
+ *   the Free Software Foundation, either version 3 of the License, or
+ *   (at your option) any later version.
+
+ *   This program is distributed in the hope that it will be useful,
+ *   but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *   GNU General Public License for more details.
+
+ *   You should have received a copy of the GNU General Public License
+ *   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package eth
+
+import (
+	"fmt"
+
+	evmcommon "github.com/ethereum/go-ethereum/common"
+	uint256 "github.com/holiman/uint256"
+)
+
+// journalEntry is a state change that can be reverted. The changes are reverted by writing the previous values back
+// into the write cache, so the read accesses made after the snapshot are still recorded for the conflict detection.
+type journalEntry interface {
+	revert(*ImplStateDB)
+}
+
+// journal keeps the state changes made in the current transaction in order, similar to its counterpart in geth.
+type journal struct {
+	entries   []journalEntry
+	snapshots []int // The journal length at each snapshot, the index is the snapshot id.
+}
+
+func newJournal() *journal {
+	return &journal{
+		entries:   []journalEntry{},
+		snapshots: []int{},
+	}
+}
+
+func (this *journal) append(entry journalEntry) { this.entries = append(this.entries, entry) }
+
+func (this *journal) reset() {
+	this.entries = this.entries[:0]
+	this.snapshots = this.snapshots[:0]
+}
+
+func (this *journal) snapshot() int {
+	this.snapshots = append(this.snapshots, len(this.entries))
+	return len(this.snapshots) - 1
+}
+
+// revertToSnapshot reverts all the changes made after the snapshot in the reverse order and discards the
+// snapshot along with all the ones taken after it.
+func (this *journal) revertToSnapshot(id int, statedb *ImplStateDB) {
+	if id < 0 || id >= len(this.snapshots) {
+		panic(fmt.Errorf("revision id %v cannot be reverted", id))
+	}
+
+	for i := len(this.entries) - 1; i >= this.snapshots[id]; i-- {
+		this.entries[i].revert(statedb)
+	}
+	this.entries = this.entries[:this.snapshots[id]]
+	this.snapshots = this.snapshots[:id]
+}
+
+type (
+	// An account was created, all its paths need to be removed.
+	createAccountChange struct {
+		account evmcommon.Address
+	}
+
+	// An account was created by CreateAccount(), it can be destructed in the same transaction.
+	createContractChange struct {
+		account evmcommon.Address
+	}
+
+	selfDestructChange struct {
+		account evmcommon.Address
+	}
+
+	balanceChange struct {
+		account    evmcommon.Address
+		amount     *uint256.Int
+		isPositive bool
+	}
+
+	// Nonces are commutative increments, they can't go down. A reverted increment is only undone if the account
+	// was created after the snapshot. The nonces of the contracts are offset anyway, see CalculateNonceOffset().
+	nonceChange struct {
+		account evmcommon.Address
+	}
+
+	codeChange struct {
+		account evmcommon.Address
+		prev    []byte
+		existed bool
+	}
+
+	storageChange struct {
+		account evmcommon.Address
+		key     evmcommon.Hash
+		prev    []byte
+		existed bool
+	}
+
+	transientStorageChange struct {
+		account   evmcommon.Address
+		key, prev evmcommon.Hash
+	}
+
+	refundChange struct {
+		prev uint64
+	}
+
+	addLogChange struct {
+		txhash evmcommon.Hash
+	}
+
+	accessListAddAccountChange struct {
+		address evmcommon.Address
+	}
+
+	accessListAddSlotChange struct {
+		address evmcommon.Address
+		slot    evmcommon.Hash
+	}
+)
+
+func (this createAccountChange) revert(statedb *ImplStateDB) {
+	statedb.deletePath(getAccountRootPath(nil, this.account))
+}
+
+func (this createContractChange) revert(statedb *ImplStateDB) {
+	delete(statedb.created, this.account)
+}
+
+func (this selfDestructChange) revert(statedb *ImplStateDB) {
+	statedb.destructed = statedb.destructed[:len(statedb.destructed)-1]
+}
+
+func (this balanceChange) revert(statedb *ImplStateDB) {
+	statedb.writeBalanceDelta(this.account, this.amount, !this.isPositive)
+}
+
+func (this nonceChange) revert(statedb *ImplStateDB) {}
+
+func (this codeChange) revert(statedb *ImplStateDB) {
+	statedb.restoreBytes(getCodePath(nil, this.account), this.prev, this.existed)
+}
+
+func (this storageChange) revert(statedb *ImplStateDB) {
+	statedb.restoreBytes(getStorageKeyPath(statedb.api, this.account, this.key), this.prev, this.existed)
+}
+
+func (this transientStorageChange) revert(statedb *ImplStateDB) {
+	statedb.setTransientState(this.account, this.key, this.prev)
+}
+
+func (this refundChange) revert(statedb *ImplStateDB) {
+	statedb.refund = this.prev
+}
+
+func (this addLogChange) revert(statedb *ImplStateDB) {
+	logs := statedb.logs[this.txhash]
+	if len(logs) == 1 {
+		delete(statedb.logs, this.txhash)
+	} else {
+		statedb.logs[this.txhash] = logs[:len(logs)-1]
+	}
+}
+
+func (this accessListAddAccountChange) revert(statedb *ImplStateDB) {
+	statedb.accessList.DeleteAddress(this.address)
+}
+
+func (this accessListAddSlotChange) revert(statedb *ImplStateDB) {
+	statedb.accessList.DeleteSlot(this.address, this.slot)
+}
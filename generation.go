@@ -18,7 +18,10 @@
 package execution
 
 import (
+	"context"
 	"errors"
+	"sort"
+	"sync/atomic"
 
 	common "github.com/arcology-network/common-lib/common"
 	slice "github.com/arcology-network/common-lib/exp/slice"
@@ -27,16 +30,26 @@ import (
 	scheduler "github.com/arcology-network/scheduler"
 	arbitrator "github.com/arcology-network/scheduler/arbitrator"
 	stgcommon "github.com/arcology-network/storage-committer/common"
+	cache "github.com/arcology-network/storage-committer/storage/cache"
 	univalue "github.com/arcology-network/storage-committer/type/univalue"
 	evmcore "github.com/ethereum/go-ethereum/core"
 )
 
+// ErrPrerequisiteFailed is the error for the sequences skipped because a sequence holding one of their prerequisite
+// transactions has failed.
+var ErrPrerequisiteFailed = errors.New("Error: Prerequisite transaction failed")
+
 // APIs under the concurrency namespace
 type Generation struct {
 	ID          uint64
 	numThreads  uint8
+	maxRetries  int                     // Max rounds to re-execute the conflicted sequences, 0 to disable.
+	policy      ConflictPolicy          // Decide which sequence wins in a conflict, nil to use the arbitrator's default.
 	jobSeqs     []*eucommon.JobSequence // para jobSeqs
 	occurrences *map[string]int
+	pool        *eucommon.EUPool // Reusable EUs for the worker threads.
+	running     atomic.Int64     // The number of the sequences being executed at the moment.
+	peak        atomic.Int64     // The max number of the sequences ever executed at the same time.
 }
 
 func (*Generation) OccurrenceDict(jobSeqs []*eucommon.JobSequence) *map[string]int {
@@ -50,11 +63,14 @@ func (*Generation) OccurrenceDict(jobSeqs []*eucommon.JobSequence) *map[string]i
 	return &occurrences
 }
 
+// NewGeneration creates a generation executing the job sequences with up to numThreads workers, at least one.
 func NewGeneration(id uint64, numThreads uint8, jobSeqs []*eucommon.JobSequence) *Generation {
+	numThreads = common.Max(numThreads, 1)
 	gen := &Generation{
 		ID:         id,
 		numThreads: numThreads,
 		jobSeqs:    jobSeqs,
+		pool:       eucommon.NewEUPool(int(numThreads)),
 	}
 	gen.occurrences = gen.OccurrenceDict(jobSeqs)
 	return gen
@@ -64,7 +80,7 @@ func NewGeneration(id uint64, numThreads uint8, jobSeqs []*eucommon.JobSequence)
 // This function converts a list of raw calls to a list of parallel job sequences. One job sequence is created for each caller.
 // If there are N callers, there will be N job sequences. There sequences will be later added to a generation and executed in parallel.
 func NewGenerationFromMsgs(id uint64, numThreads uint8, evmMsgs []*evmcore.Message, api intf.EthApiRouter) *Generation {
-	gen := NewGeneration(id, uint8(len(evmMsgs)), []*eucommon.JobSequence{})
+	gen := NewGeneration(id, numThreads, make([]*eucommon.JobSequence, 0, len(evmMsgs)))
 	slice.Foreach(evmMsgs, func(i int, msg **evmcore.Message) {
 		gen.Add(new(eucommon.JobSequence).NewFromCall(*msg, api.GetEU().(interface{ TxHash() [32]byte }).TxHash(), api))
 	})
@@ -74,11 +90,15 @@ func NewGenerationFromMsgs(id uint64, numThreads uint8, evmMsgs []*evmcore.Messa
 }
 
 func (this *Generation) Length() uint64              { return uint64(len(this.jobSeqs)) }
+func (this *Generation) NumThreads() uint8           { return this.numThreads }
 func (this *Generation) JobT() *eucommon.JobSequence { return &eucommon.JobSequence{} }
 func (this *Generation) JobSeqs() []*eucommon.JobSequence {
 	return slice.To[*eucommon.JobSequence, *eucommon.JobSequence](this.jobSeqs)
 }
 
+// PeakParallelism returns the max number of the sequences that have been executed at the same time.
+func (this *Generation) PeakParallelism() int { return int(this.peak.Load()) }
+
 func (this *Generation) At(idx uint64) *eucommon.JobSequence {
 	return common.IfThenDo1st(idx < uint64(len(this.jobSeqs)), func() *eucommon.JobSequence { return this.jobSeqs[idx] }, nil)
 }
@@ -87,6 +107,30 @@ func (*Generation) New(id uint64, numThreads uint8, jobSeqs []*eucommon.JobSeque
 	return NewGeneration(id, numThreads, slice.To[*eucommon.JobSequence, *eucommon.JobSequence](jobSeqs))
 }
 
+// SetMaxRetries enables the retry mode. The conflicted sequences will be re-executed against the cleared
+// transitions of the winners for up to the given number of rounds or until there is no conflict left.
+func (this *Generation) SetMaxRetries(rounds int) *Generation {
+	this.maxRetries = rounds
+	return this
+}
+
+func (this *Generation) MaxRetries() int { return this.maxRetries }
+
+// SetEUPool shares a pool of EUs with the other generations, so the EUs are reused across the blocks as well.
+// A nil pool disables the reuse.
+func (this *Generation) SetEUPool(pool *eucommon.EUPool) *Generation {
+	this.pool = pool
+	return this
+}
+
+// SetConflictPolicy sets the policy used to pick the winners among the conflicting sequences.
+func (this *Generation) SetConflictPolicy(policy ConflictPolicy) *Generation {
+	this.policy = policy
+	return this
+}
+
+func (this *Generation) ConflictPolicy() ConflictPolicy { return this.policy }
+
 func (this *Generation) Add(job *eucommon.JobSequence) bool {
 	this.jobSeqs = append(this.jobSeqs, job)
 	return true
@@ -109,28 +153,232 @@ func (this *Generation) Add(job *eucommon.JobSequence) bool {
 // their contracts at different addresses.
 
 func (this *Generation) Execute(execCoinbase interface{}, blockAPI intf.EthApiRouter) []*univalue.Univalue {
+	return this.ExecuteWithContext(context.Background(), execCoinbase, blockAPI)
+}
+
+// ExecuteWithContext executes the job sequences like Execute but within the context. Once the context is done, the
+// running jobs are cancelled and the ones not started yet are skipped, they are all marked with ErrExecutionTimeout.
+// The transitions of the jobs finished in time are still returned.
+func (this *Generation) ExecuteWithContext(ctx context.Context, execCoinbase interface{}, blockAPI intf.EthApiRouter) []*univalue.Univalue {
 	config := execCoinbase.(*eucommon.Config)
 
-	seqIDs := make([][]uint64, len(this.jobSeqs))
-	records := make([][]*univalue.Univalue, len(this.jobSeqs))
+	// The sequences are executed wave by wave. A sequence only starts after all the sequences holding its
+	// prerequisite transactions have finished, and their cleared transitions are visible to it through the cache.
+	// Without any dependencies, all the sequences are in the same wave and executed in parallel. The transitions
+	// are returned in the order of the waves, so the later writes always come after the ones they depend on. The
+	// state changes of a failed sequence are never committed, the sequences depending on it are skipped and marked
+	// with ErrPrerequisiteFailed.
+	rejected, holders := this.reject(config), this.holders()
+
+	api, transitions, waveTransitions := blockAPI, []*univalue.Univalue{}, []*univalue.Univalue{}
+	for _, wave := range this.Waves() {
+		runnable := make([]*eucommon.JobSequence, 0, len(wave))
+		for _, seq := range wave {
+			if rejected[seq] {
+				continue
+			}
+
+			if idx, _ := slice.FindFirstIf(seq.PreTxs, func(_ int, tx uint64) bool {
+				j, ok := holders[tx]
+				return ok && this.jobSeqs[j] != seq && failed(this.jobSeqs[j])
+			}); idx >= 0 {
+				seq.Fail(config, ErrPrerequisiteFailed)
+				continue
+			}
+			runnable = append(runnable, seq)
+		}
+
+		if wave = runnable; len(wave) == 0 {
+			continue
+		}
+
+		if len(transitions) > 0 {
+			api = this.cascade(api, waveTransitions)
+		}
+		waveTransitions = this.executeWave(ctx, config, api, wave)
+		transitions = append(transitions, waveTransitions...)
+	}
+	return transitions
+}
+
+// reject fails the sequences that can't be executed before the execution starts. The conflict policies rank the
+// transactions by their positions in the sequences, the positions of the ones sharing an ID can't be told apart.
+func (this *Generation) reject(config *eucommon.Config) map[*eucommon.JobSequence]bool {
+	rejected := map[*eucommon.JobSequence]bool{}
+	if this.policy == nil {
+		return rejected
+	}
+
+	for _, seq := range this.jobSeqs {
+		ids := map[uint64]bool{}
+		for _, job := range seq.Jobs {
+			if ids[job.StdMsg.ID] {
+				seq.Fail(config, ErrDuplicateTxID)
+				rejected[seq] = true
+				break
+			}
+			ids[job.StdMsg.ID] = true
+		}
+	}
+	return rejected
+}
+
+// failed returns true if any job in the sequence has failed, its transitions are dropped then. The sequences not
+// executed yet haven't failed.
+func failed(seq *eucommon.JobSequence) bool {
+	for _, job := range seq.Jobs {
+		if job.Results != nil && job.Results.Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// holders maps the transaction IDs to the indices of the sequences holding them.
+func (this *Generation) holders() map[uint64]int {
+	holders := map[uint64]int{}
+	for i, seq := range this.jobSeqs {
+		for _, job := range seq.Jobs {
+			holders[job.StdMsg.ID] = i
+		}
+	}
+	return holders
+}
+
+// Waves groups the job sequences by their dependencies defined in the PreTxs. The sequences in the same wave
+// don't depend on each other. The sequences in a circular dependency end up in the last waves in no particular order.
+func (this *Generation) Waves() [][]*eucommon.JobSequence {
+	holders := this.holders() // Tx ID -> the index of the sequence holding it
+
+	levels := make([]int, len(this.jobSeqs))
+	for round := 0; round <= len(this.jobSeqs); round++ { // No dependency chain is longer than the number of sequences.
+		changed := false
+		for i, seq := range this.jobSeqs {
+			for _, tx := range seq.PreTxs {
+				if j, ok := holders[tx]; ok && j != i && levels[i] <= levels[j] {
+					levels[i], changed = levels[j]+1, true
+				}
+			}
+		}
+
+		if !changed {
+			break
+		}
+	}
+
+	_, maxLevel := slice.Max(append([]int{0}, levels...))
+	waves := make([][]*eucommon.JobSequence, maxLevel+1)
+	for i, seq := range this.jobSeqs {
+		waves[levels[i]] = append(waves[levels[i]], seq)
+	}
+	return slice.RemoveIf(&waves, func(_ int, wave []*eucommon.JobSequence) bool { return len(wave) == 0 })
+}
+
+// executeWave executes the job sequences in parallel, flags the conflicting ones and returns the conflict-free
+// transitions of the wave. In the retry mode, the conflicted sequences are re-executed against a cache holding
+// the cleared transitions of the sequences accepted so far, and the transitions are returned in the order of the
+// rounds they were accepted in. The winners are always chosen by the arbitrator, which only depends on the IDs
+// not the scheduling, so the final transitions are the same regardless of the number of threads.
+func (this *Generation) executeWave(ctx context.Context, config *eucommon.Config, api intf.EthApiRouter, jobSeqs []*eucommon.JobSequence) []*univalue.Univalue {
+	txDict, seqDict := this.execute(ctx, config, api, jobSeqs)
+
+	accepted := []*univalue.Univalue{}
+	for round := 0; round < this.maxRetries && len(seqDict) > 0 && ctx.Err() == nil; round++ {
+		winners := slice.CopyIf(jobSeqs, func(_ int, seq *eucommon.JobSequence) bool { _, ok := seqDict[seq.ID]; return !ok })
+		accepted = append(accepted, slice.Concate(winners, func(seq *eucommon.JobSequence) []*univalue.Univalue {
+			return seq.GetClearedTransition()
+		})...)
+
+		jobSeqs = slice.CopyIf(jobSeqs, func(_ int, seq *eucommon.JobSequence) bool { _, ok := seqDict[seq.ID]; return ok })
+		txDict, seqDict = this.execute(ctx, config, this.cascade(api, accepted), jobSeqs)
+	}
+
+	// Mark the conflicts in the job sequences left.
+	for _, seq := range jobSeqs {
+		if _, ok := seqDict[seq.ID]; ok { // Check if the sequence ID is in the conflict list.
+			seq.FlagConflict(txDict, errors.New(stgcommon.WARN_ACCESS_CONFLICT))
+		}
+	}
+
+	// The winners of each round come first, in the order of the rounds, then the sequences of the last round.
+	return append(accepted, slice.Concate(jobSeqs, func(seq *eucommon.JobSequence) []*univalue.Univalue {
+		return seq.GetClearedTransition() // Return the conflict-free transitions
+	})...)
+}
+
+// cascade creates a new cache layer on top of the api with the given transitions inserted.
+func (this *Generation) cascade(api intf.EthApiRouter, transitions []*univalue.Univalue) intf.EthApiRouter {
+	cascaded := api.Cascade()
+	cascaded.DecrementDepth() // Not a new level of execution, just a new cache layer.
+	cascaded.WriteCache().(*cache.WriteCache).Insert(slice.Transform(transitions, func(_ int, v *univalue.Univalue) *univalue.Univalue {
+		return v.Clone().(*univalue.Univalue) // The cache may modify the transitions, so clone them first.
+	}))
+	return cascaded
+}
+
+// execute runs the given job sequences in parallel and returns the conflicting transactions and sequences.
+func (this *Generation) execute(ctx context.Context, config *eucommon.Config, api intf.EthApiRouter, jobSeqs []*eucommon.JobSequence) (map[uint64]uint64, map[uint64]uint64) {
+	seqIDs := make([][]uint64, len(jobSeqs))
+	records := make([][]*univalue.Univalue, len(jobSeqs))
 
 	// Execute the job sequences in parallel. All the access records from the same sequence share
 	// the same sequence ID. The sequence ID is used to detect the conflicts between different sequences.
-	slice.ParallelForeach(this.jobSeqs, int(this.numThreads), func(i int, _ **eucommon.JobSequence) {
-		seqIDs[i], records[i] = this.jobSeqs[i].Run(config, blockAPI.Cascade(), uint64(i))
+	slice.ParallelForeach(jobSeqs, int(this.numThreads), func(i int, _ **eucommon.JobSequence) {
+		running := this.running.Add(1) // Track the peak parallelism
+		for peak := this.peak.Load(); running > peak && !this.peak.CompareAndSwap(peak, running); peak = this.peak.Load() {
+		}
+		defer this.running.Add(-1)
+
+		seqIDs[i], records[i] = jobSeqs[i].RunWithContext(ctx, config, api.Cascade(), this.pool, uint64(i))
 	})
 
-	conflictInfo := this.Detect(seqIDs, records)
-	txDict, seqDict, _ := conflictInfo.ToDict()
+	return this.detect(jobSeqs, seqIDs, records)
+}
+
+// detect finds the conflicts between the sequences. The arbitrator always keeps the transaction with the lowest ID,
+// so the sequences are ranked by the conflict policy first and their ranks are used as the transaction IDs during
+// the detection. The original IDs are restored afterwards.
+func (this *Generation) detect(jobSeqs []*eucommon.JobSequence, seqIDs [][]uint64, records [][]*univalue.Univalue) (map[uint64]uint64, map[uint64]uint64) {
+	if this.policy == nil {
+		txDict, seqDict, _ := this.Detect(seqIDs, records).ToDict()
+		return txDict, seqDict
+	}
+
+	order := slice.NewDo(len(jobSeqs), func(i int) int { return i })
+	sort.SliceStable(order, func(i, j int) bool { return this.policy.Less(jobSeqs[order[i]], jobSeqs[order[j]]) })
 
-	// Mark the conflicts in the job sequences.
-	cleanTrans := slice.Concate(this.jobSeqs, func(seq *eucommon.JobSequence) []*univalue.Univalue {
-		if _, ok := seqDict[(*seq).ID]; ok { // Check if the sequence ID is in the conflict list.
-			(*seq).FlagConflict(txDict, errors.New(stgcommon.WARN_ACCESS_CONFLICT))
+	// Leave enough room for all the transactions in the same sequence.
+	stride := uint64(1)
+	for _, seq := range jobSeqs {
+		stride = common.Max(stride, uint64(seq.Length()))
+	}
+
+	flattened := slice.Flatten(records)
+	originals := slice.Transform(flattened, func(_ int, v *univalue.Univalue) uint64 { return v.GetTx() })
+
+	ranked := map[uint64]uint64{}
+	for rank, i := range order {
+		offsets := map[uint64]uint64{} // The IDs are unique in each sequence, see reject().
+		for j, job := range jobSeqs[i].Jobs {
+			offsets[job.StdMsg.ID] = uint64(j)
 		}
-		return (*seq).GetClearedTransition() // Return the conflict-free transitions
-	})
-	return cleanTrans
+
+		for _, v := range records[i] {
+			rankedTx := uint64(rank)*stride + offsets[v.GetTx()]
+			ranked[rankedTx] = v.GetTx()
+			v.SetTx(rankedTx)
+		}
+	}
+
+	conflicts := this.Detect(seqIDs, records)
+	slice.Foreach(flattened, func(i int, v **univalue.Univalue) { (*v).SetTx(originals[i]) })
+
+	rankedTxDict, seqDict, _ := conflicts.ToDict()
+	txDict := make(map[uint64]uint64, len(rankedTxDict))
+	for k, v := range rankedTxDict {
+		txDict[ranked[k]] += v
+	}
+	return txDict, seqDict
 }
 
 // There needs to be a sequence ID for each transaction in the sequence, not just the transaction ID because
@@ -0,0 +1,97 @@
+/*
+ *   Copyright (c) 2025 Arcology Network
+
+ *   This program is free software: you can redistribute it and/or modify
+ *   it under the terms of the GNU General Public License as published by
+ *   the Free Software Foundation, either version 3 of the License, or
+ *   (at your option) any later version.
+
+ *   This program is distributed in the hope that it will be useful,
+ *   but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *   GNU General Public License for more details.
+
+ *   You should have received a copy of the GNU General Public License
+ *   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package execution
+
+import (
+	"testing"
+
+	commontype "github.com/arcology-network/common-lib/types"
+	eucommon "github.com/arcology-network/eu/common"
+)
+
+func newTestSeq(id uint64, txs []uint64, preTxs ...uint64) *eucommon.JobSequence {
+	seq := &eucommon.JobSequence{ID: id, PreTxs: preTxs}
+	for _, tx := range txs {
+		seq.AppendMsg(&commontype.StandardMessage{ID: tx})
+	}
+	return seq
+}
+
+func TestGenerationWaves(t *testing.T) {
+	gen := NewGeneration(0, 4, []*eucommon.JobSequence{
+		newTestSeq(0, []uint64{10}),         // No dependency
+		newTestSeq(1, []uint64{11}, 10),     // Depends on seq 0
+		newTestSeq(2, []uint64{12, 13}),     // No dependency
+		newTestSeq(3, []uint64{14}, 11, 13), // Depends on seq 1 and 2
+		newTestSeq(4, []uint64{15}, 99),     // Unknown dependency, ignored
+	})
+
+	waves := gen.Waves()
+	if len(waves) != 3 {
+		t.Fatalf("Expected 3 waves, got %d", len(waves))
+	}
+
+	ids := func(wave []*eucommon.JobSequence) []uint64 {
+		v := []uint64{}
+		for _, seq := range wave {
+			v = append(v, seq.ID)
+		}
+		return v
+	}
+
+	if v := ids(waves[0]); len(v) != 3 || v[0] != 0 || v[1] != 2 || v[2] != 4 {
+		t.Error("Wrong first wave:", v)
+	}
+
+	if v := ids(waves[1]); len(v) != 1 || v[0] != 1 {
+		t.Error("Wrong second wave:", v)
+	}
+
+	if v := ids(waves[2]); len(v) != 1 || v[0] != 3 {
+		t.Error("Wrong third wave:", v)
+	}
+}
+
+func TestGenerationWavesWithoutDependencies(t *testing.T) {
+	gen := NewGeneration(0, 4, []*eucommon.JobSequence{
+		newTestSeq(0, []uint64{0}),
+		newTestSeq(1, []uint64{1}),
+		newTestSeq(2, []uint64{2}),
+	})
+
+	if waves := gen.Waves(); len(waves) != 1 || len(waves[0]) != 3 {
+		t.Error("All the sequences should be in the same wave")
+	}
+}
+
+func TestGenerationWavesCircular(t *testing.T) {
+	gen := NewGeneration(0, 4, []*eucommon.JobSequence{
+		newTestSeq(0, []uint64{0}, 1),
+		newTestSeq(1, []uint64{1}, 0),
+		newTestSeq(2, []uint64{2}),
+	})
+
+	total := 0
+	for _, wave := range gen.Waves() {
+		total += len(wave)
+	}
+
+	if total != 3 {
+		t.Error("Every sequence should be in a wave, got", total)
+	}
+}
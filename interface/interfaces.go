@@ -67,6 +67,7 @@ type EthApiRouter interface {
 	SetWriteCache(any) EthApiRouter
 	New(any, any, evmcommon.Address, any) EthApiRouter
 	Cascade() EthApiRouter
+	CascadeTo(EthApiRouter) EthApiRouter // Like Cascade(), but reuses the given router instead of creating a new one.
 
 	Origin() evmcommon.Address
 	Coinbase() evmcommon.Address
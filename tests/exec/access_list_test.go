@@ -0,0 +1,107 @@
+/*
+ *   Copyright (c) 2025 Arcology Network
+
+ *   This program is free software: you can redistribute it and/or modify
+ *   it under the terms of the GNU General Public License as published by
+ *   the Free Software Foundation, either version 3 of the License, or
+ *   (at your option) any later version.
+
+ *   This program is distributed in the hope that it will be useful,
+ *   but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *   GNU General Public License for more details.
+
+ *   You should have received a copy of the GNU General Public License
+ *   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package exectest
+
+import (
+	"math/big"
+	"testing"
+
+	commontype "github.com/arcology-network/common-lib/types"
+	eucommon "github.com/arcology-network/eu/common"
+	ethimpl "github.com/arcology-network/eu/eth"
+	evmcommon "github.com/ethereum/go-ethereum/common"
+	evmcore "github.com/ethereum/go-ethereum/core"
+	evmtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestAccessListSeedAndRevert(t *testing.T) {
+	testEu := NewTestEU(Coinbase, Alice, Bob)
+	statedb := ethimpl.NewImplStateDB(testEu.eu.Api())
+	statedb.PrepareFormer(evmcommon.Hash{}, evmcommon.Hash{}, 1)
+
+	listed := evmcommon.BytesToAddress([]byte("listed"))
+	precompile := evmcommon.BytesToAddress([]byte{1})
+	statedb.Prepare(params.Rules{IsBerlin: true}, Alice, Coinbase, &Bob, []evmcommon.Address{precompile}, evmtypes.AccessList{
+		{Address: listed, StorageKeys: []evmcommon.Hash{{1}}},
+	})
+
+	for _, addr := range []evmcommon.Address{Alice, Bob, precompile, listed, eucommon.IO_HANDLER} {
+		if !statedb.AddressInAccessList(addr) {
+			t.Error("Expected a warm address", addr)
+		}
+	}
+
+	if statedb.AddressInAccessList(Coinbase) {
+		t.Error("The coinbase is only warm after Shanghai")
+	}
+
+	if _, ok := statedb.SlotInAccessList(listed, evmcommon.Hash{1}); !ok {
+		t.Error("Expected a warm slot")
+	}
+
+	snapshot := statedb.Snapshot()
+	statedb.AddAddressToAccessList(Abby)
+	statedb.AddSlotToAccessList(Bob, evmcommon.Hash{2})
+	statedb.RevertToSnapshot(snapshot)
+
+	if statedb.AddressInAccessList(Abby) {
+		t.Error("The address should be cold again after the revert")
+	}
+
+	if addrOk, slotOk := statedb.SlotInAccessList(Bob, evmcommon.Hash{2}); !addrOk || slotOk {
+		t.Error("The slot should be cold again after the revert")
+	}
+}
+
+// Load the same slot twice, the first one is cold and the second one is warm, the same as in geth.
+func TestAccessListGasUsed(t *testing.T) {
+	contract := evmcommon.BytesToAddress([]byte("sload"))
+	code := []byte{
+		0x60, 0x00, 0x54, 0x50, // POP(SLOAD(0))
+		0x60, 0x00, 0x54, 0x50, // POP(SLOAD(0))
+		0x00, // STOP
+	}
+
+	run := func(list evmtypes.AccessList) uint64 {
+		testEu := NewTestEU(Coinbase, Alice)
+		statedb := ethimpl.NewImplStateDB(testEu.eu.Api())
+		statedb.PrepareFormer(evmcommon.Hash{}, evmcommon.Hash{}, 0)
+		statedb.CreateAccount(contract)
+		statedb.SetCode(contract, code)
+
+		msg := evmcore.NewMessage(Alice, &contract, 0, new(big.Int), 1e6, big.NewInt(1), nil, list, false)
+		job := &eucommon.Job{StdMsg: &commontype.StandardMessage{ID: 1, TxHash: [32]byte{1}, Native: &msg}}
+
+		receipt, result, err := testEu.eu.Run(job, eucommon.NewEVMBlockContext(testEu.config), eucommon.NewEVMTxContext(msg))
+		if err != nil || result.Err != nil || receipt.Status != 1 {
+			t.Fatal("The call should succeed", err, result.Err)
+		}
+		return receipt.GasUsed
+	}
+
+	// 21000 + (3 + 2100 + 2) + (3 + 100 + 2)
+	if gas := run(nil); gas != 23210 {
+		t.Error("Expected 23210 gas, got", gas)
+	}
+
+	// 21000 + 2400 + 1900 + (3 + 100 + 2) * 2
+	if gas := run(evmtypes.AccessList{{Address: contract, StorageKeys: []evmcommon.Hash{{}}}}); gas != 25510 {
+		t.Error("Expected 25510 gas, got", gas)
+	}
+}
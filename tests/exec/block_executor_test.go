@@ -0,0 +1,191 @@
+/*
+ *   Copyright (c) 2025 Arcology Network
+
+ *   This program is free software: you can redistribute it and/or modify
+ *   it under the terms of the GNU General Public License as published by
+ *   the Free Software Foundation, either version 3 of the License, or
+ *   (at your option) any later version.
+
+ *   This program is distributed in the hope that it will be useful,
+ *   but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *   GNU General Public License for more details.
+
+ *   You should have received a copy of the GNU General Public License
+ *   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package exectest
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	commontype "github.com/arcology-network/common-lib/types"
+	eu "github.com/arcology-network/eu"
+	eucommon "github.com/arcology-network/eu/common"
+	stgcommon "github.com/arcology-network/storage-committer/common"
+	evmcommon "github.com/ethereum/go-ethereum/common"
+	evmtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// Emits two logs with the topics 7 and 8: MSTORE(0, 42) LOG1(0, 32, 7) LOG1(0, 32, 8) STOP
+var logger = []byte{
+	0x60, 0x2a, 0x60, 0x00, 0x52,
+	0x60, 0x07, 0x60, 0x20, 0x60, 0x00, 0xa1,
+	0x60, 0x08, 0x60, 0x20, 0x60, 0x00, 0xa1,
+	0x00,
+}
+
+func TestBlockExecutor(t *testing.T) {
+	testEu := NewTestEU(Coinbase, Alice, Bob)
+
+	msgs := []*commontype.StandardMessage{
+		newTestSeq(1, Alice, Abby, 0, 100, 1e6).Jobs[0].StdMsg,
+		newTestSeq(2, Bob, Andy, 0, 100, 1e6).Jobs[0].StdMsg,
+	}
+
+	result, err := eu.NewBlockExecutor(testEu.config, 2).Execute(context.Background(), msgs, testEu.eu.Api())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Receipts) != 2 || len(result.Results) != 2 {
+		t.Fatal("Expected 2 receipts, got", len(result.Receipts))
+	}
+
+	cumulative := uint64(0)
+	for i, receipt := range result.Receipts {
+		if receipt.Status != 1 || receipt.TransactionIndex != uint(i) {
+			t.Error("Wrong receipt", i, receipt.Status, receipt.TransactionIndex)
+		}
+
+		cumulative += receipt.GasUsed
+		if receipt.CumulativeGasUsed != cumulative {
+			t.Error("Expected cumulative gas", cumulative, "got", receipt.CumulativeGasUsed)
+		}
+	}
+
+	if result.GasUsed != cumulative || result.GasUsed == 0 {
+		t.Error("Wrong total gas used", result.GasUsed)
+	}
+
+	if len(result.Transitions) == 0 {
+		t.Error("Expected some transitions")
+	}
+}
+
+func TestBlockExecutorFailures(t *testing.T) {
+	testEu := NewTestEU(Coinbase, Alice, Bob, Abby, Andy, Anna)
+	loggerAddr := setTestCode(testEu, "logger", logger)
+	counterAddr := setTestCode(testEu, "counter", counter)
+	endlessAddr := setTestCode(testEu, "endless", endlessLoop)
+	testEu.config.JobTimeout = 100 * time.Millisecond
+
+	msgs := []*commontype.StandardMessage{
+		newTestSeq(1, Alice, loggerAddr, 0, 0, 1e6).Jobs[0].StdMsg,
+		newTestSeq(2, Bob, loggerAddr, 0, 0, 1e6).Jobs[0].StdMsg,
+		newTestSeq(3, Abby, counterAddr, 0, 0, 1e6).Jobs[0].StdMsg,
+		newTestSeq(4, Andy, counterAddr, 0, 0, 1e6).Jobs[0].StdMsg,  // Loses the conflict to the previous one
+		newTestSeq(5, Abu, Bob, 0, 100, 1e6).Jobs[0].StdMsg,         // No balance to pay for the gas
+		newTestSeq(6, Anna, endlessAddr, 0, 0, 1e12).Jobs[0].StdMsg, // Runs into the timeout
+	}
+
+	result, err := eu.NewBlockExecutor(testEu.config, 4).Execute(context.Background(), msgs, testEu.eu.Api())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Results) != 6 {
+		t.Fatal("Expected 6 results, got", len(result.Results))
+	}
+
+	if result.Results[4].PrecheckErr == nil {
+		t.Error("Expected the precheck to fail")
+	}
+
+	if err := result.Results[3].Err; err == nil || err.Error() != stgcommon.WARN_ACCESS_CONFLICT {
+		t.Error("Expected a conflict, got", err)
+	}
+
+	if err := result.Results[5].Err; err != eucommon.ErrExecutionTimeout {
+		t.Error("Expected the timeout error, got", err)
+	}
+
+	// The precheck failure isn't part of the block, the conflicted and the timed-out ones are but failed.
+	if len(result.Receipts) != 5 {
+		t.Fatal("Expected 5 receipts, got", len(result.Receipts))
+	}
+
+	cumulative := uint64(0)
+	for i, expected := range []struct {
+		txHash [32]byte
+		status uint64
+		logs   int
+	}{
+		{[32]byte{2}, evmtypes.ReceiptStatusSuccessful, 2},
+		{[32]byte{3}, evmtypes.ReceiptStatusSuccessful, 2},
+		{[32]byte{4}, evmtypes.ReceiptStatusSuccessful, 0},
+		{[32]byte{5}, evmtypes.ReceiptStatusFailed, 0},
+		{[32]byte{7}, evmtypes.ReceiptStatusFailed, 0},
+	} {
+		receipt := result.Receipts[i]
+		if receipt.TxHash != expected.txHash || receipt.Status != expected.status || len(receipt.Logs) != expected.logs {
+			t.Errorf("Receipt %d: expected (%x, %d, %d logs), got (%x, %d, %d logs)", i, expected.txHash[0], expected.status, expected.logs, receipt.TxHash[0], receipt.Status, len(receipt.Logs))
+		}
+
+		if receipt.TransactionIndex != uint(i) {
+			t.Error("Expected the transaction index", i, "got", receipt.TransactionIndex)
+		}
+
+		cumulative += receipt.GasUsed
+		if receipt.CumulativeGasUsed != cumulative {
+			t.Error("Expected cumulative gas", cumulative, "got", receipt.CumulativeGasUsed)
+		}
+	}
+
+	if result.GasUsed != cumulative {
+		t.Error("Expected the total gas", cumulative, "got", result.GasUsed)
+	}
+
+	// The log indices are block-wide.
+	index := uint(0)
+	for _, receipt := range result.Receipts {
+		for _, log := range receipt.Logs {
+			if log.Index != index || log.TxIndex != receipt.TransactionIndex || log.TxHash != receipt.TxHash {
+				t.Error("Wrong log", log.Index, log.TxIndex, "expected index", index)
+			}
+			index++
+		}
+	}
+
+	if index != 4 {
+		t.Error("Expected 4 logs, got", index)
+	}
+
+	for _, topic := range []int64{7, 8} {
+		if !evmtypes.BloomLookup(result.Bloom, evmcommon.BigToHash(big.NewInt(topic))) {
+			t.Error("The block bloom doesn't have the topic", topic)
+		}
+
+		if !evmtypes.BloomLookup(result.Receipts[0].Bloom, evmcommon.BigToHash(big.NewInt(topic))) {
+			t.Error("The receipt bloom doesn't have the topic", topic)
+		}
+	}
+
+	if evmtypes.BloomLookup(result.Receipts[2].Bloom, evmcommon.BigToHash(big.NewInt(7))) {
+		t.Error("The counter doesn't emit any logs")
+	}
+}
+
+func TestBlockExecutorWithoutBlockNumber(t *testing.T) {
+	testEu := NewTestEU(Coinbase, Alice, Bob)
+	testEu.config.BlockNumber = nil
+
+	msgs := []*commontype.StandardMessage{newTestSeq(1, Alice, Abby, 0, 100, 1e6).Jobs[0].StdMsg}
+	if _, err := eu.NewBlockExecutor(testEu.config, 2).Execute(context.Background(), msgs, testEu.eu.Api()); err != eu.ErrMissingBlockNumber {
+		t.Error("Expected the missing block number to be rejected, got", err)
+	}
+}
@@ -0,0 +1,156 @@
+/*
+ *   Copyright (c) 2025 Arcology Network
+
+ *   This program is free software: you can redistribute it and/or modify
+ *   it under the terms of the GNU General Public License as published by
+ *   the Free Software Foundation, either version 3 of the License, or
+ *   (at your option) any later version.
+
+ *   This program is distributed in the hope that it will be useful,
+ *   but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *   GNU General Public License for more details.
+
+ *   You should have received a copy of the GNU General Public License
+ *   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package exectest
+
+import (
+	"math/big"
+	"testing"
+
+	eu "github.com/arcology-network/eu"
+	eucommon "github.com/arcology-network/eu/common"
+	stgcommon "github.com/arcology-network/storage-committer/common"
+	evmcommon "github.com/ethereum/go-ethereum/common"
+	evmcore "github.com/ethereum/go-ethereum/core"
+)
+
+// The counter plus three more slots: SSTORE(0, SLOAD(0) + 1) SSTORE(1, 1) SSTORE(2, 1) SSTORE(3, 1) STOP
+var wideCounter = []byte{
+	0x60, 0x00, 0x54, 0x60, 0x01, 0x01, 0x60, 0x00, 0x55,
+	0x60, 0x01, 0x60, 0x01, 0x55,
+	0x60, 0x01, 0x60, 0x02, 0x55,
+	0x60, 0x01, 0x60, 0x03, 0x55,
+	0x00,
+}
+
+// newPolicyTestSeq creates a sequence with one job per target, the jobs take the transaction IDs in order.
+func newPolicyTestSeq(id uint64, txs []uint64, from evmcommon.Address, gasPrice int64, targets ...evmcommon.Address) *eucommon.JobSequence {
+	msgs, hashes := make([]*evmcore.Message, len(targets)), make([][32]byte, len(targets))
+	for i := range targets {
+		msg := evmcore.NewMessage(from, &targets[i], 0, new(big.Int), 1e6, big.NewInt(gasPrice), nil, nil, false)
+		msgs[i], hashes[i] = &msg, [32]byte{byte(txs[i])}
+	}
+	return eucommon.NewJobSequence(id, txs, msgs, hashes, nil)
+}
+
+// Every policy picks a different winner from the default arbitration, which keeps the lowest transaction ID.
+func TestConflictPoliciesChangeTheWinner(t *testing.T) {
+	type contracts struct{ counter, wide evmcommon.Address }
+
+	for _, test := range []struct {
+		name   string
+		policy eu.ConflictPolicy
+		seqs   func(contracts) []*eucommon.JobSequence
+		winner uint64 // The winner under the policy, the other sequence wins by default.
+	}{
+		{
+			name:   "LowestSeqIDWins",
+			policy: eu.LowestSeqIDWins{},
+			seqs: func(c contracts) []*eucommon.JobSequence {
+				return []*eucommon.JobSequence{
+					newPolicyTestSeq(1, []uint64{20}, Alice, 1, c.counter),
+					newPolicyTestSeq(2, []uint64{10}, Bob, 1, c.counter), // The lower transaction ID
+				}
+			},
+			winner: 1,
+		},
+		{
+			name:   "FirstInBlockWins",
+			policy: eu.FirstInBlockWins{},
+			seqs: func(c contracts) []*eucommon.JobSequence {
+				return []*eucommon.JobSequence{
+					newPolicyTestSeq(1, []uint64{1, 5}, Alice, 1, Abby, c.counter), // First in the block but conflicts later
+					newPolicyTestSeq(2, []uint64{3}, Bob, 1, c.counter),
+				}
+			},
+			winner: 1,
+		},
+		{
+			name:   "HighestGasPriceWins",
+			policy: eu.HighestGasPriceWins{},
+			seqs: func(c contracts) []*eucommon.JobSequence {
+				return []*eucommon.JobSequence{
+					newPolicyTestSeq(1, []uint64{1, 2}, Alice, 1, Abby, c.counter),
+					newPolicyTestSeq(2, []uint64{3}, Bob, 10, c.counter), // Pays more
+				}
+			},
+			winner: 2,
+		},
+		{
+			name:   "FewestAccessesWins",
+			policy: eu.FewestAccessesWins{},
+			seqs: func(c contracts) []*eucommon.JobSequence {
+				return []*eucommon.JobSequence{
+					newPolicyTestSeq(1, []uint64{1}, Alice, 1, c.wide),
+					newPolicyTestSeq(2, []uint64{2}, Bob, 1, c.counter), // Accesses fewer states
+				}
+			},
+			winner: 2,
+		},
+	} {
+		for _, policy := range []eu.ConflictPolicy{nil, test.policy} {
+			testEu := NewTestEU(Coinbase, Alice, Bob)
+			c := contracts{setTestCode(testEu, "counter", counter), setTestCode(testEu, "wide", wideCounter)}
+
+			seqs := test.seqs(c)
+			eu.NewGeneration(0, 2, seqs).SetConflictPolicy(policy).Execute(testEu.config, testEu.eu.Api())
+
+			winner := test.winner
+			if policy == nil {
+				winner = 3 - test.winner // The other one
+			}
+
+			for _, seq := range seqs {
+				last := len(seq.Jobs) - 1 // The conflicting job is always the last one.
+				for i, job := range seq.Jobs {
+					conflicted := job.Results.Err != nil && job.Results.Err.Error() == stgcommon.WARN_ACCESS_CONFLICT
+					if expected := seq.ID != winner && i == last; conflicted != expected {
+						t.Errorf("%s with policy %v: job %d of sequence %d, expected conflicted = %v, got %v", test.name, policy, i, seq.ID, expected, job.Results.Err)
+					}
+				}
+			}
+		}
+	}
+}
+
+// The positions of the transactions sharing an ID can't be ranked, so the sequences holding them are rejected under a policy.
+func TestConflictPolicyRejectsDuplicateTxIDs(t *testing.T) {
+	for _, policy := range []eu.ConflictPolicy{nil, eu.FirstInBlockWins{}} {
+		testEu := NewTestEU(Coinbase, Alice, Bob)
+		target := setTestCode(testEu, "counter", counter)
+
+		seqs := []*eucommon.JobSequence{
+			newPolicyTestSeq(1, []uint64{1, 1}, Alice, 1, Abby, target), // Two transactions with the same ID
+			newPolicyTestSeq(2, []uint64{2}, Bob, 1, Abby),
+		}
+		eu.NewGeneration(0, 2, seqs).SetConflictPolicy(policy).Execute(testEu.config, testEu.eu.Api())
+
+		for _, job := range seqs[0].Jobs {
+			if rejected := job.Results.Err == eu.ErrDuplicateTxID; rejected != (policy != nil) {
+				t.Errorf("Policy %v: expected rejected = %v, got %v", policy, policy != nil, job.Results.Err)
+			}
+
+			if policy != nil && (job.Results.Receipt == nil || job.Results.Receipt.Status != 0 || job.Results.Receipt.GasUsed != 0) {
+				t.Errorf("Policy %v: expected a failed receipt using no gas", policy)
+			}
+		}
+
+		if err := seqs[1].Jobs[0].Results.Err; err != nil {
+			t.Errorf("Policy %v: expected the other sequence to succeed, got %v", policy, err)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+/*
+ *   Copyright (c) 2025 Arcology Network
+
+ *   This program is free software: you can redistribute it and/or modify
+ *   it under the terms of the GNU General Public License as published by
+ *   the Free Software Foundation, either version 3 of the License, or
+ *   (at your option) any later version.
+
+ *   This program is distributed in the hope that it will be useful,
+ *   but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *   GNU General Public License for more details.
+
+ *   You should have received a copy of the GNU General Public License
+ *   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package exectest
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	apihandler "github.com/arcology-network/eu/apihandler"
+	apiruntime "github.com/arcology-network/eu/apihandler/runtime"
+	eucommon "github.com/arcology-network/eu/common"
+)
+
+func TestConsoleLog(t *testing.T) {
+	testEu := NewTestEU(Coinbase, Alice)
+	caller := newHandlerCaller(testEu, eucommon.IO_HANDLER)
+
+	if _, ok := caller.call(false, "log(string,uint256,bool,address)", packArgs([]string{"string", "uint256", "bool", "address"}, "balance", big.NewInt(42), true, Bob)); !ok {
+		t.Fatal("Failed to log")
+	}
+
+	if _, ok := caller.call(false, "log(int)", int256Arg(-7)); !ok {
+		t.Fatal("Failed to log with the legacy signature")
+	}
+
+	if _, ok := caller.call(false, "unknown(uint256)", int256Arg(1)); !ok {
+		t.Error("An unknown signature shouldn't fail the call")
+	}
+
+	msgs := []apiruntime.ConsoleLog{}
+	for _, log := range testEu.eu.Api().(*apihandler.APIHandler).GetLogs() {
+		if log.GetByKey() != apiruntime.CONSOLE_LOG_KEY {
+			continue
+		}
+
+		var msg apiruntime.ConsoleLog
+		if err := json.Unmarshal([]byte(log.GetValue()), &msg); err != nil {
+			t.Fatal(err)
+		}
+		msgs = append(msgs, msg)
+	}
+
+	if len(msgs) != 3 {
+		t.Fatal("Expected 3 console logs, got", len(msgs))
+	}
+
+	if expected := "balance 42 true " + Bob.Hex(); msgs[0].Message != expected {
+		t.Error("Expected", expected, "got", msgs[0].Message)
+	}
+
+	if msgs[1].Message != "-7" || msgs[1].TxHash[0] != 2 || msgs[1].Error != "" {
+		t.Error("Wrong console log", msgs[1])
+	}
+
+	if msgs[2].Message != "" || !strings.Contains(msgs[2].Error, hex.EncodeToString(caller.calldata(false, "unknown(uint256)", int256Arg(1))[1:])) {
+		t.Error("Expected the undecodable input to be logged, got", msgs[2])
+	}
+}
@@ -0,0 +1,377 @@
+/*
+ *   Copyright (c) 2025 Arcology Network
+
+ *   This program is free software: you can redistribute it and/or modify
+ *   it under the terms of the GNU General Public License as published by
+ *   the Free Software Foundation, either version 3 of the License, or
+ *   (at your option) any later version.
+
+ *   This program is distributed in the hope that it will be useful,
+ *   but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *   GNU General Public License for more details.
+
+ *   You should have received a copy of the GNU General Public License
+ *   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package exectest
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	eu "github.com/arcology-network/eu"
+	apicontainer "github.com/arcology-network/eu/apihandler/container"
+	eucommon "github.com/arcology-network/eu/common"
+	ethimpl "github.com/arcology-network/eu/eth"
+	stgcommon "github.com/arcology-network/storage-committer/common"
+	noncommutative "github.com/arcology-network/storage-committer/type/noncommutative"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	evmcommon "github.com/ethereum/go-ethereum/common"
+	evmcore "github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// packArgs ABI encodes the values with the types, it panics on the unsupported types.
+func packArgs(types []string, values ...any) []byte {
+	args := abi.Arguments{}
+	for _, typ := range types {
+		t, err := abi.NewType(typ, "", nil)
+		if err != nil {
+			panic(err)
+		}
+		args = append(args, abi.Argument{Type: t})
+	}
+
+	encoded, err := args.Pack(values...)
+	if err != nil {
+		panic(err)
+	}
+	return encoded
+}
+
+// evalArg wraps a container function call into the argument of eval(bytes).
+func evalArg(signature string, types []string, values ...any) []byte {
+	return packArgs([]string{"bytes"}, append(crypto.Keccak256([]byte(signature))[:4], packArgs(types, values...)...))
+}
+
+// newContainer creates a container of the element type in the proxy contract.
+func newContainer(t *testing.T, elemType uint8) *handlerCaller {
+	caller := newHandlerCaller(NewTestEU(Coinbase, Alice), eucommon.BYTES_HANDLER)
+	if _, ok := caller.call(false, "new(uint8,bool)", packArgs([]string{"uint8", "bool"}, elemType, false)); !ok {
+		t.Fatal("Failed to create the container")
+	}
+	return caller
+}
+
+func TestContainerGetRange(t *testing.T) {
+	caller := newContainer(t, noncommutative.BYTES)
+
+	for _, key := range []string{"a", "b", "c"} {
+		if _, ok := caller.call(false, "eval(bytes)", evalArg("setByKey(bytes,bytes)", []string{"bytes", "bytes"}, []byte(key), []byte("value-"+key))); !ok {
+			t.Fatal("Failed to set", key)
+		}
+	}
+
+	bytesArray, _ := abi.NewType("bytes[]", "", nil)
+	getRange := func(arg []byte) ([][]byte, [][]byte) {
+		ret, ok := caller.call(true, "eval(bytes)", arg)
+		if !ok {
+			t.Fatal("Failed to get the range")
+		}
+
+		decoded, err := abi.Arguments{{Type: bytesArray}, {Type: bytesArray}}.Unpack(ret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return decoded[0].([][]byte), decoded[1].([][]byte)
+	}
+
+	// The count is clamped to the end of the container.
+	keys, values := getRange(evalArg("getRange(uint256,uint256)", []string{"uint256", "uint256"}, big.NewInt(1), big.NewInt(10)))
+	if len(keys) != 2 || len(values) != 2 {
+		t.Fatal("Expected 2 elements, got", len(keys), len(values))
+	}
+
+	if !bytes.Equal(keys[0], []byte("b")) || !bytes.Equal(values[1], []byte("value-c")) {
+		t.Error("Wrong range", keys, values)
+	}
+
+	keys, values = getRange(evalArg("getRangeByKey(bytes,uint256)", []string{"bytes", "uint256"}, []byte("a"), big.NewInt(2)))
+	if len(keys) != 2 || !bytes.Equal(keys[1], []byte("b")) || !bytes.Equal(values[0], []byte("value-a")) {
+		t.Error("Wrong range", keys, values)
+	}
+
+	if _, ok := caller.call(true, "eval(bytes)", evalArg("getRangeByKey(bytes,uint256)", []string{"bytes", "uint256"}, []byte("x"), big.NewInt(2))); ok {
+		t.Error("The range of a missing key should fail")
+	}
+}
+
+func TestContainerBatch(t *testing.T) {
+	caller := newContainer(t, noncommutative.BYTES)
+
+	boolArray, _ := abi.NewType("bool[]", "", nil)
+	bytesArray, _ := abi.NewType("bytes[]", "", nil)
+	keys := [][]byte{[]byte("a"), []byte("b"), []byte("c")}
+
+	ret, ok := caller.call(false, "eval(bytes)", evalArg("setBatch(bytes[],bytes[])", []string{"bytes[]", "bytes[]"}, keys, [][]byte{[]byte("1"), []byte("2"), []byte("3")}))
+	if !ok {
+		t.Fatal("Failed to set the batch")
+	}
+
+	if decoded, err := (abi.Arguments{{Type: boolArray}}).Unpack(ret); err != nil || len(decoded[0].([]bool)) != 3 || !decoded[0].([]bool)[2] {
+		t.Error("Wrong flags", decoded, err)
+	}
+
+	if _, ok := caller.call(false, "eval(bytes)", evalArg("delBatch(bytes[])", []string{"bytes[]"}, keys[1:2])); !ok {
+		t.Fatal("Failed to delete the batch")
+	}
+
+	ret, ok = caller.call(true, "eval(bytes)", evalArg("getBatch(bytes[])", []string{"bytes[]"}, append(keys, []byte("x"))))
+	if !ok {
+		t.Fatal("Failed to get the batch")
+	}
+
+	decoded, err := abi.Arguments{{Type: boolArray}, {Type: bytesArray}}.Unpack(ret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flags, values := decoded[0].([]bool), decoded[1].([][]byte)
+	if len(flags) != 4 || !flags[0] || flags[1] || !flags[2] || flags[3] {
+		t.Error("Wrong flags", flags)
+	}
+
+	if !bytes.Equal(values[0], []byte("1")) || !bytes.Equal(values[2], []byte("3")) || len(values[1]) != 0 {
+		t.Error("Wrong values", values)
+	}
+}
+
+func TestContainerTypedElements(t *testing.T) {
+	set := func(caller *handlerCaller, key string, value []byte) bool {
+		_, ok := caller.call(false, "eval(bytes)", evalArg("setByKey(bytes,bytes)", []string{"bytes", "bytes"}, []byte(key), value))
+		return ok
+	}
+
+	get := func(caller *handlerCaller, key string) []byte {
+		ret, ok := caller.call(true, "eval(bytes)", evalArg("getByKey(bytes)", []string{"bytes"}, []byte(key)))
+		if !ok {
+			t.Fatal("Failed to get", key)
+		}
+		return ret
+	}
+
+	addresses := newContainer(t, apicontainer.ADDRESS)
+	if !set(addresses, "alice", packArgs([]string{"address"}, Alice)) {
+		t.Fatal("Failed to set the address")
+	}
+
+	if ret := get(addresses, "alice"); !bytes.Equal(ret, packArgs([]string{"address"}, Alice)) {
+		t.Error("Wrong address", ret)
+	}
+
+	if set(addresses, "bad", packArgs([]string{"uint256"}, new(big.Int).Lsh(big.NewInt(1), 200))) {
+		t.Error("A value longer than an address should be rejected")
+	}
+
+	flags := newContainer(t, apicontainer.BOOL)
+	if !set(flags, "on", packArgs([]string{"bool"}, true)) || set(flags, "bad", packArgs([]string{"uint256"}, big.NewInt(2))) {
+		t.Error("Wrong bool checks")
+	}
+
+	if ret := get(flags, "on"); !bytes.Equal(ret, packArgs([]string{"bool"}, true)) {
+		t.Error("Wrong bool", ret)
+	}
+
+	strs := newContainer(t, noncommutative.STRING)
+	str := "a string longer than thirty-two bytes"
+	if !set(strs, "k", packArgs([]string{"string"}, str)) {
+		t.Fatal("Failed to set the string")
+	}
+
+	if ret := get(strs, "k"); !bytes.Equal(ret, packArgs([]string{"string"}, str)) {
+		t.Error("Wrong string", ret)
+	}
+
+	// Unsupported element types are rejected when creating the container.
+	caller := newHandlerCaller(NewTestEU(Coinbase, Alice), eucommon.BYTES_HANDLER)
+	if _, ok := caller.call(false, "new(uint8,bool)", packArgs([]string{"uint8", "bool"}, uint8(255), false)); ok {
+		t.Error("The container of an unsupported type should be rejected")
+	}
+}
+
+func TestContainerMinMaxComparators(t *testing.T) {
+	caller := newContainer(t, noncommutative.BYTES)
+	for i, v := range []int64{5, -3, 7} {
+		key := []byte{byte(i)}
+		if _, ok := caller.call(false, "eval(bytes)", evalArg("setByKey(bytes,bytes)", []string{"bytes", "bytes"}, key, int256Arg(v))); !ok {
+			t.Fatal("Failed to set", v)
+		}
+	}
+
+	extreme := func(signature string, mode uint8) uint64 {
+		ret, ok := caller.call(true, "eval(bytes)", evalArg(signature, []string{"uint8"}, mode))
+		if !ok || len(ret) < 32 {
+			t.Fatal("Failed to call", signature, mode)
+		}
+		return new(big.Int).SetBytes(ret[:32]).Uint64()
+	}
+
+	// -3 is the largest as an unsigned integer but the smallest as an int256.
+	if idx := extreme("max(uint8)", apicontainer.CMP_UINT); idx != 1 {
+		t.Error("Expected index 1, got", idx)
+	}
+
+	if idx := extreme("min(uint8)", apicontainer.CMP_INT256); idx != 1 {
+		t.Error("Expected index 1, got", idx)
+	}
+
+	if idx := extreme("max(uint8)", apicontainer.CMP_INT256); idx != 2 {
+		t.Error("Expected index 2, got", idx)
+	}
+
+	if _, ok := caller.call(true, "eval(bytes)", evalArg("min(uint8)", []string{"uint8"}, uint8(9))); ok {
+		t.Error("An unknown comparator should fail")
+	}
+}
+
+func TestComparatorContainer(t *testing.T) {
+	caller := newHandlerCaller(NewTestEU(Coinbase, Alice), eucommon.BYTES_HANDLER)
+	if _, ok := caller.call(false, "newWithComparator(uint8,bool,uint8)", packArgs([]string{"uint8", "bool", "uint8"}, noncommutative.BYTES, false, apicontainer.CMP_INT256)); !ok {
+		t.Fatal("Failed to create the container")
+	}
+
+	for i, v := range []int64{5, -3, 7} {
+		if _, ok := caller.call(false, "eval(bytes)", evalArg("setByKey(bytes,bytes)", []string{"bytes", "bytes"}, []byte{byte(i)}, int256Arg(v))); !ok {
+			t.Fatal("Failed to set", v)
+		}
+	}
+
+	bytesType, _ := abi.NewType("bytes", "", nil)
+	entry := func(isStatic bool, arg []byte) ([]byte, int64) {
+		ret, ok := caller.call(isStatic, "eval(bytes)", arg)
+		if !ok {
+			t.Fatal("The call failed")
+		}
+
+		decoded, err := abi.Arguments{{Type: bytesType}, {Type: bytesType}}.Unpack(ret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return decoded[0].([]byte), new(big.Int).SetBytes(decoded[1].([]byte)).Int64()
+	}
+
+	if key, v := entry(true, evalArg("lowerBound(bytes)", []string{"bytes"}, int256Arg(5))); key[0] != 0 || v != 5 {
+		t.Error("Wrong lower bound", key, v)
+	}
+
+	if key, v := entry(true, evalArg("upperBound(bytes)", []string{"bytes"}, int256Arg(5))); key[0] != 2 || v != 7 {
+		t.Error("Wrong upper bound", key, v)
+	}
+
+	if key, _ := entry(false, evalArg("popMin()", nil)); key[0] != 1 {
+		t.Error("Expected to pop -3, got", key)
+	}
+
+	if key, v := entry(false, evalArg("popMax()", nil)); key[0] != 2 || v != 7 {
+		t.Error("Expected to pop 7, got", key, v)
+	}
+
+	if key, v := entry(false, evalArg("popMin()", nil)); key[0] != 0 || v != 5 {
+		t.Error("Expected to pop 5, got", key, v)
+	}
+
+	if _, ok := caller.call(false, "eval(bytes)", evalArg("popMin()", nil)); ok {
+		t.Error("Popping from an empty container should fail")
+	}
+
+	// The bounds only work with the containers with a comparator.
+	caller = newContainer(t, noncommutative.BYTES)
+	if _, ok := caller.call(true, "eval(bytes)", evalArg("lowerBound(bytes)", []string{"bytes"}, int256Arg(5))); ok {
+		t.Error("Expected to fail on a container without a comparator")
+	}
+}
+
+func TestContainerEvalOf(t *testing.T) {
+	owner := newContainer(t, noncommutative.BYTES)
+	if _, ok := owner.call(false, "eval(bytes)", evalArg("setByKey(bytes,bytes)", []string{"bytes", "bytes"}, []byte("k"), []byte("v"))); !ok {
+		t.Fatal("Failed to set")
+	}
+
+	// Another proxy contract reading the container of the owner.
+	other := evmcommon.BytesToAddress([]byte("other"))
+	statedb := ethimpl.NewImplStateDB(owner.testEu.eu.Api())
+	statedb.PrepareFormer(evmcommon.Hash{}, evmcommon.Hash{}, 0)
+	statedb.CreateAccount(other)
+	statedb.SetCode(other, handlerProxy(eucommon.BYTES_HANDLER))
+	reader := &handlerCaller{testEu: owner.testEu, proxy: other, txID: 100}
+
+	evalOf := func(addr evmcommon.Address, arg []byte) ([]byte, bool) {
+		return reader.call(true, "evalOf(address,bytes)", packArgs([]string{"address", "bytes"}, addr, arg))
+	}
+
+	if ret, ok := evalOf(owner.proxy, append(crypto.Keccak256([]byte("getByKey(bytes)"))[:4], packArgs([]string{"bytes"}, []byte("k"))...)); !ok || !bytes.Equal(ret, []byte("v")) {
+		t.Error("Failed to read the container of the owner", ret)
+	}
+
+	if _, ok := evalOf(owner.proxy, append(crypto.Keccak256([]byte("delByKey(bytes)"))[:4], packArgs([]string{"bytes"}, []byte("k"))...)); ok {
+		t.Error("Only the owner can write to its container")
+	}
+
+	if _, ok := evalOf(Bob, crypto.Keccak256([]byte("fullLength()"))[:4]); ok {
+		t.Error("The owner doesn't have a container")
+	}
+}
+
+// Two transactions insert different keys into the same container with a comparator at the same time, they don't conflict.
+// Popping the smallest element scans all the elements, so it does conflict with an insert running at the same time.
+func TestComparatorContainerConcurrentInserts(t *testing.T) {
+	insert := func(key byte, v int64) []byte {
+		return evalArg("setByKey(bytes,bytes)", []string{"bytes", "bytes"}, []byte{key}, int256Arg(v))
+	}
+
+	for _, test := range []struct {
+		name       string
+		first      []byte // The other transaction inserts a new key.
+		conflicted bool
+	}{
+		{"insert", insert(1, 3), false},
+		{"popMin", evalArg("popMin()", nil), true},
+	} {
+		testEu := NewTestEU(Coinbase, Alice, Bob)
+		caller := newHandlerCaller(testEu, eucommon.BYTES_HANDLER)
+		if _, ok := caller.call(false, "newWithComparator(uint8,bool,uint8)", packArgs([]string{"uint8", "bool", "uint8"}, noncommutative.BYTES, false, apicontainer.CMP_INT256)); !ok {
+			t.Fatal("Failed to create the container")
+		}
+
+		if _, ok := caller.call(false, "eval(bytes)", insert(0, 5)); !ok {
+			t.Fatal("Failed to insert the first element")
+		}
+
+		seqs := make([]*eucommon.JobSequence, 2)
+		for i, arg := range [][]byte{test.first, insert(2, 9)} {
+			id := caller.txID + uint64(i) + 1
+			msg := evmcore.NewMessage([]evmcommon.Address{Alice, Bob}[i], &caller.proxy, 0, new(big.Int), 1e6, big.NewInt(1), caller.calldata(false, "eval(bytes)", arg), nil, false)
+			seqs[i] = eucommon.NewJobSequence(id, []uint64{id}, []*evmcore.Message{&msg}, [][32]byte{{byte(id + 1)}}, nil)
+		}
+		eu.NewGeneration(0, 2, seqs).Execute(testEu.config, testEu.eu.Api())
+
+		for i, seq := range seqs {
+			if receipt := seq.Jobs[0].Results.Receipt; receipt == nil || receipt.Status != 1 {
+				t.Fatalf("%s: transaction %d failed", test.name, i)
+			}
+		}
+
+		if err := seqs[0].Jobs[0].Results.Err; err != nil {
+			t.Errorf("%s: the first transaction should win, got %v", test.name, err)
+		}
+
+		// The later transaction loses the conflict, if there is one.
+		err := seqs[1].Jobs[0].Results.Err
+		if conflicted := err != nil && err.Error() == stgcommon.WARN_ACCESS_CONFLICT; conflicted != test.conflicted {
+			t.Errorf("%s: expected conflicted = %v, got %v", test.name, test.conflicted, err)
+		}
+	}
+}
@@ -0,0 +1,456 @@
+/*
+ *   Copyright (c) 2025 Arcology Network
+
+ *   This program is free software: you can redistribute it and/or modify
+ *   it under the terms of the GNU General Public License as published by
+ *   the Free Software Foundation, either version 3 of the License, or
+ *   (at your option) any later version.
+
+ *   This program is distributed in the hope that it will be useful,
+ *   but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *   GNU General Public License for more details.
+
+ *   You should have received a copy of the GNU General Public License
+ *   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package exectest
+
+import (
+	"math/big"
+	"testing"
+
+	commontype "github.com/arcology-network/common-lib/types"
+	apihandler "github.com/arcology-network/eu/apihandler"
+	eucommon "github.com/arcology-network/eu/common"
+	ethimpl "github.com/arcology-network/eu/eth"
+	evmcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	evmcore "github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// handlerProxy returns the code of a contract forwarding the calldata after the first byte to the handler, with a
+// STATICCALL if the first byte is 1 or a CALL otherwise. It returns or reverts with whatever the handler returns.
+func handlerProxy(handler [20]byte) []byte {
+	code := []byte{
+		0x60, 0x01, 0x36, 0x03, // size = CALLDATASIZE - 1
+		0x80, 0x60, 0x01, 0x60, 0x00, 0x37, // CALLDATACOPY(0, 1, size)
+		0x60, 0x00, 0x35, 0x60, 0xf8, 0x1c, // CALLDATALOAD(0) >> 248
+		0x60, 0x36, 0x57, // JUMPI(static)
+		0x60, 0x00, 0x60, 0x00, 0x82, 0x60, 0x00, 0x60, 0x00, 0x73, // retSize, retOffset, size, argsOffset, value, PUSH20
+	}
+	code = append(code, handler[:]...)
+	code = append(code,
+		0x5a, 0xf1, // CALL(GAS, handler, ...)
+		0x60, 0x55, 0x56, // JUMP(end)
+		0x5b,                                           // static:
+		0x60, 0x00, 0x60, 0x00, 0x82, 0x60, 0x00, 0x73, // retSize, retOffset, size, argsOffset, PUSH20
+	)
+	code = append(code, handler[:]...)
+	return append(code,
+		0x5a, 0xfa, // STATICCALL(GAS, handler, ...)
+		0x5b,                               // end:
+		0x3d, 0x60, 0x00, 0x60, 0x00, 0x3e, // RETURNDATACOPY(0, 0, RETURNDATASIZE)
+		0x60, 0x63, 0x57, // JUMPI(ok)
+		0x3d, 0x60, 0x00, 0xfd, // REVERT(0, RETURNDATASIZE)
+		0x5b,                   // ok:
+		0x3d, 0x60, 0x00, 0xf3, // RETURN(0, RETURNDATASIZE)
+	)
+}
+
+// handlerCaller calls a handler through a proxy contract, one transaction per call.
+type handlerCaller struct {
+	testEu *TestEu
+	proxy  evmcommon.Address
+	txID   uint64
+}
+
+func newHandlerCaller(testEu *TestEu, handler [20]byte) *handlerCaller {
+	proxy := evmcommon.BytesToAddress([]byte("proxy"))
+	statedb := ethimpl.NewImplStateDB(testEu.eu.Api())
+	statedb.PrepareFormer(evmcommon.Hash{}, evmcommon.Hash{}, 0)
+	statedb.CreateAccount(proxy)
+	statedb.SetCode(proxy, handlerProxy(handler))
+	return &handlerCaller{testEu: testEu, proxy: proxy}
+}
+
+// calldata encodes the call to the handler for the proxy contract.
+func (this *handlerCaller) calldata(isStatic bool, signature string, args ...[]byte) []byte {
+	data := []byte{0}
+	if isStatic {
+		data[0] = 1
+	}
+
+	data = append(data, crypto.Keccak256([]byte(signature))[:4]...)
+	for _, arg := range args {
+		data = append(data, arg...)
+	}
+	return data
+}
+
+// call sends the selector of the function signature and the arguments to the handler, it returns the data returned
+// by the handler and whether the call has succeeded.
+func (this *handlerCaller) call(isStatic bool, signature string, args ...[]byte) ([]byte, bool) {
+	this.txID++
+	msg := evmcore.NewMessage(Alice, &this.proxy, 0, new(big.Int), 1e6, big.NewInt(1), this.calldata(isStatic, signature, args...), nil, false)
+	job := &eucommon.Job{StdMsg: &commontype.StandardMessage{ID: this.txID, TxHash: [32]byte{byte(this.txID)}, Native: &msg}}
+
+	receipt, result, err := this.testEu.eu.Run(job, eucommon.NewEVMBlockContext(this.testEu.config), eucommon.NewEVMTxContext(msg))
+	return result.ReturnData, err == nil && receipt.Status == 1
+}
+
+// retarget points the proxy to another handler, so the calls to both handlers come from the same contract.
+func (this *handlerCaller) retarget(handler [20]byte) *handlerCaller {
+	statedb := ethimpl.NewImplStateDB(this.testEu.eu.Api())
+	statedb.PrepareFormer(evmcommon.Hash{}, evmcommon.Hash{}, 0)
+	statedb.SetCode(this.proxy, handlerProxy(handler))
+	return this
+}
+
+func int256Arg(v int64) []byte { return math.U256Bytes(big.NewInt(v)) }
+
+func TestInt256Cumulative(t *testing.T) {
+	caller := newHandlerCaller(NewTestEU(Coinbase, Alice), eucommon.CUMULATIVE_I256_HANDLER)
+
+	get := func(signature string) int64 {
+		ret, ok := caller.call(true, signature)
+		if !ok || len(ret) != 32 {
+			t.Fatal("Failed to call", signature)
+		}
+		return math.S256(new(big.Int).SetBytes(ret)).Int64()
+	}
+
+	if _, ok := caller.call(false, "new(int256,int256)", int256Arg(-100), int256Arg(100)); !ok {
+		t.Fatal("Failed to create the variable")
+	}
+
+	if v := get("get()"); v != 0 {
+		t.Error("Expected 0, got", v)
+	}
+
+	if min, max := get("min()"), get("max()"); min != -100 || max != 100 {
+		t.Error("Wrong bounds", min, max)
+	}
+
+	// Negative deltas
+	caller.call(false, "add(int256)", int256Arg(-30))
+	if v := get("get()"); v != -30 {
+		t.Error("Expected -30, got", v)
+	}
+
+	caller.call(false, "sub(int256)", int256Arg(-50))
+	if v := get("get()"); v != 20 {
+		t.Error("Expected 20, got", v)
+	}
+
+	// Past the bounds
+	if _, ok := caller.call(false, "add(int256)", int256Arg(81)); ok {
+		t.Error("Adding past the upper bound should fail")
+	}
+
+	if _, ok := caller.call(false, "sub(int256)", int256Arg(121)); ok {
+		t.Error("Subtracting past the lower bound should fail")
+	}
+
+	if v := get("get()"); v != 20 {
+		t.Error("The failed calls shouldn't change the value, got", v)
+	}
+
+	// Get is read only
+	if _, ok := caller.call(false, "get()"); ok {
+		t.Error("Get should only work in a static call")
+	}
+}
+
+// The variables start from the lower bound if 0 is out of the bounds.
+func TestInt256CumulativeInitialValue(t *testing.T) {
+	for _, bounds := range [][2]int64{{10, 100}, {-100, -10}} {
+		caller := newHandlerCaller(NewTestEU(Coinbase, Alice), eucommon.CUMULATIVE_I256_HANDLER)
+		if _, ok := caller.call(false, "new(int256,int256)", int256Arg(bounds[0]), int256Arg(bounds[1])); !ok {
+			t.Fatal("Failed to create the variable", bounds)
+		}
+
+		ret, ok := caller.call(true, "get()")
+		if !ok || math.S256(new(big.Int).SetBytes(ret)).Int64() != bounds[0] {
+			t.Error("Expected the variable to start from", bounds[0], "got", math.S256(new(big.Int).SetBytes(ret)))
+		}
+
+		if _, ok := caller.call(false, "sub(int256)", int256Arg(1)); ok {
+			t.Error("Subtracting below the lower bound should fail", bounds)
+		}
+
+		if _, ok := caller.call(false, "add(int256)", int256Arg(bounds[1]-bounds[0])); !ok {
+			t.Error("Failed to add up to the upper bound", bounds)
+		}
+
+		if _, ok := caller.call(false, "add(int256)", int256Arg(1)); ok {
+			t.Error("Adding past the upper bound should fail", bounds)
+		}
+	}
+}
+
+// The handlers keep their variables apart, even if the same contract uses all of them.
+func TestCumulativeHandlersAreIndependent(t *testing.T) {
+	caller := newHandlerCaller(NewTestEU(Coinbase, Alice), eucommon.CUMULATIVE_U256_HANDLER)
+	if _, ok := caller.call(false, "new(uint256,uint256)", int256Arg(0), int256Arg(100)); !ok {
+		t.Fatal("Failed to create the U256 variable")
+	}
+	caller.call(false, "add(uint256)", int256Arg(7))
+
+	caller.retarget(eucommon.CUMULATIVE_I256_HANDLER)
+	if _, ok := caller.call(false, "new(int256,int256)", int256Arg(-100), int256Arg(100)); !ok {
+		t.Fatal("Failed to create the int256 variable")
+	}
+	caller.call(false, "add(int256)", int256Arg(-3))
+
+	if ret, ok := caller.call(true, "get()"); !ok || math.S256(new(big.Int).SetBytes(ret)).Int64() != -3 {
+		t.Error("Expected the int256 variable to be -3, got", math.S256(new(big.Int).SetBytes(ret)))
+	}
+
+	caller.retarget(eucommon.CUMULATIVE_U256_HANDLER)
+	if ret, ok := caller.call(true, "get()"); !ok || new(big.Int).SetBytes(ret).Uint64() != 7 {
+		t.Error("Expected the U256 variable to be 7, got", new(big.Int).SetBytes(ret))
+	}
+
+	if ret, ok := caller.call(true, "min()"); !ok || new(big.Int).SetBytes(ret).Sign() != 0 {
+		t.Error("Expected the lower bound of the U256 variable to be 0, got", new(big.Int).SetBytes(ret))
+	}
+
+	// The named variables with the same ID
+	id := evmcommon.Hash{1}.Bytes()
+	caller.call(false, "new(bytes32,uint256,uint256)", id, int256Arg(0), int256Arg(100))
+	caller.call(false, "add(bytes32,uint256)", id, int256Arg(50))
+
+	caller.retarget(eucommon.CUMULATIVE_U64_HANDLER)
+	if _, ok := caller.call(false, "new(uint64,uint64)", int256Arg(0), int256Arg(10)); !ok {
+		t.Fatal("Failed to create the uint64 variable")
+	}
+	caller.call(false, "add(uint64)", int256Arg(2))
+
+	if _, ok := caller.call(false, "new(bytes32,uint64,uint64)", id, int256Arg(0), int256Arg(10)); !ok {
+		t.Fatal("Failed to create the named uint64 variable")
+	}
+	caller.call(false, "add(bytes32,uint64)", id, int256Arg(1))
+
+	if ret, ok := caller.call(true, "get()"); !ok || new(big.Int).SetBytes(ret).Uint64() != 2 {
+		t.Error("Expected the uint64 variable to be 2, got", new(big.Int).SetBytes(ret))
+	}
+
+	if ret, ok := caller.call(true, "get(bytes32)", id); !ok || new(big.Int).SetBytes(ret).Uint64() != 1 {
+		t.Error("Expected the named uint64 variable to be 1, got", new(big.Int).SetBytes(ret))
+	}
+
+	caller.retarget(eucommon.CUMULATIVE_U256_HANDLER)
+	if ret, ok := caller.call(true, "get(bytes32)", id); !ok || new(big.Int).SetBytes(ret).Uint64() != 50 {
+		t.Error("Expected the named U256 variable to be 50, got", new(big.Int).SetBytes(ret))
+	}
+
+	if ret, ok := caller.call(true, "get()"); !ok || new(big.Int).SetBytes(ret).Uint64() != 7 {
+		t.Error("Expected the U256 variable to still be 7, got", new(big.Int).SetBytes(ret))
+	}
+}
+
+func TestU256CumulativeNamedVariables(t *testing.T) {
+	caller := newHandlerCaller(NewTestEU(Coinbase, Alice), eucommon.CUMULATIVE_U256_HANDLER)
+	first, second := evmcommon.Hash{1}.Bytes(), evmcommon.Hash{2}.Bytes()
+
+	get := func(id []byte) uint64 {
+		ret, ok := caller.call(true, "get(bytes32)", id)
+		if !ok || len(ret) != 32 {
+			t.Fatal("Failed to get the variable", id)
+		}
+		return new(big.Int).SetBytes(ret).Uint64()
+	}
+
+	caller.call(false, "new(bytes32,uint256,uint256)", first, int256Arg(0), int256Arg(100))
+	caller.call(false, "new(bytes32,uint256,uint256)", second, int256Arg(0), int256Arg(10))
+
+	caller.call(false, "add(bytes32,uint256)", first, int256Arg(50))
+	caller.call(false, "add(bytes32,uint256)", second, int256Arg(5))
+	caller.call(false, "sub(bytes32,uint256)", first, int256Arg(20))
+
+	if v1, v2 := get(first), get(second); v1 != 30 || v2 != 5 {
+		t.Error("Expected 30 and 5, got", v1, v2)
+	}
+
+	// Each variable has its own bounds.
+	if _, ok := caller.call(false, "add(bytes32,uint256)", second, int256Arg(6)); ok {
+		t.Error("Adding past the upper bound of the second variable should fail")
+	}
+
+	if ret, ok := caller.call(true, "max(bytes32)", first); !ok || new(big.Int).SetBytes(ret).Uint64() != 100 {
+		t.Error("Wrong upper bound of the first variable")
+	}
+
+	// The default variable doesn't exist.
+	if _, ok := caller.call(true, "get()"); ok {
+		t.Error("The default variable shouldn't exist")
+	}
+}
+
+func TestUint64Cumulative(t *testing.T) {
+	caller := newHandlerCaller(NewTestEU(Coinbase, Alice), eucommon.CUMULATIVE_U64_HANDLER)
+
+	get := func(signature string) uint64 {
+		ret, ok := caller.call(true, signature)
+		if !ok || len(ret) != 32 {
+			t.Fatal("Failed to call", signature)
+		}
+		return new(big.Int).SetBytes(ret).Uint64()
+	}
+
+	if _, ok := caller.call(false, "new(uint64,uint64)", int256Arg(0), int256Arg(10)); !ok {
+		t.Fatal("Failed to create the variable")
+	}
+
+	caller.call(false, "add(uint64)", int256Arg(4))
+	caller.call(false, "add(uint64)", int256Arg(5))
+	if v := get("get()"); v != 9 {
+		t.Error("Expected 9, got", v)
+	}
+
+	if _, ok := caller.call(false, "add(uint64)", int256Arg(2)); ok {
+		t.Error("Adding past the upper bound should fail")
+	}
+
+	if _, ok := caller.call(false, "sub(uint64)", int256Arg(3)); !ok {
+		t.Error("Failed to decrement")
+	}
+
+	if v := get("get()"); v != 6 {
+		t.Error("Expected 6, got", v)
+	}
+
+	if _, ok := caller.call(false, "sub(uint64)", int256Arg(7)); ok {
+		t.Error("Subtracting below the lower bound should fail")
+	}
+
+	if v := get("get()"); v != 6 {
+		t.Error("The failed calls shouldn't change the value, got", v)
+	}
+
+	if min, max := get("min()"), get("max()"); min != 0 || max != 10 {
+		t.Error("Wrong bounds", min, max)
+	}
+}
+
+func TestUint64CumulativeBounds(t *testing.T) {
+	caller := newHandlerCaller(NewTestEU(Coinbase, Alice), eucommon.CUMULATIVE_U64_HANDLER)
+	id := evmcommon.Hash{1}.Bytes()
+
+	get := func() uint64 {
+		ret, ok := caller.call(true, "get(bytes32)", id)
+		if !ok || len(ret) != 32 {
+			t.Fatal("Failed to get the variable")
+		}
+		return new(big.Int).SetBytes(ret).Uint64()
+	}
+
+	if _, ok := caller.call(false, "new(bytes32,uint64,uint64)", id, int256Arg(10), int256Arg(5)); ok {
+		t.Error("The lower bound can't be greater than the upper bound")
+	}
+
+	if _, ok := caller.call(false, "new(bytes32,uint64,uint64)", id, int256Arg(0), int256Arg(20)); !ok {
+		t.Fatal("Failed to create the variable")
+	}
+
+	// Down to the lower bound exactly, then one more.
+	caller.call(false, "add(bytes32,uint64)", id, int256Arg(5))
+	if _, ok := caller.call(false, "sub(bytes32,uint64)", id, int256Arg(5)); !ok || get() != 0 {
+		t.Error("Failed to decrement to the lower bound")
+	}
+
+	if _, ok := caller.call(false, "sub(bytes32,uint64)", id, int256Arg(1)); ok || get() != 0 {
+		t.Error("The variable shouldn't underflow")
+	}
+
+	// Up to the upper bound exactly, then one more.
+	if _, ok := caller.call(false, "add(bytes32,uint64)", id, int256Arg(20)); !ok || get() != 20 {
+		t.Error("Failed to increment to the upper bound")
+	}
+
+	if _, ok := caller.call(false, "add(bytes32,uint64)", id, int256Arg(1)); ok || get() != 20 {
+		t.Error("The variable shouldn't overflow")
+	}
+}
+
+func TestU256CumulativeGas(t *testing.T) {
+	testEu := NewTestEU(Coinbase, Alice)
+	caller := newHandlerCaller(testEu, eucommon.CUMULATIVE_U256_HANDLER)
+	first, second := evmcommon.Hash{1}.Bytes(), evmcommon.Hash{2}.Bytes()
+
+	// Run a transaction first, so the EU has a job for the direct calls below.
+	if _, ok := caller.call(false, "new(bytes32,uint256,uint256)", first, int256Arg(0), int256Arg(100)); !ok {
+		t.Fatal("Failed to create the variable")
+	}
+
+	handler := testEu.eu.Api().(*apihandler.APIHandler).HandlerDict()[eucommon.CUMULATIVE_U256_HANDLER]
+	for _, op := range []struct {
+		isReadOnly bool
+		signature  string
+		args       [][]byte
+		flat       int64
+	}{
+		{false, "new(bytes32,uint256,uint256)", [][]byte{second, int256Arg(0), int256Arg(100)}, eucommon.GAS_DECODE*2 + eucommon.GAS_WRITE},
+		{false, "add(bytes32,uint256)", [][]byte{first, int256Arg(10)}, eucommon.GAS_DECODE + eucommon.GAS_DELTA_WRITE},
+		{false, "sub(bytes32,uint256)", [][]byte{first, int256Arg(5)}, eucommon.GAS_DECODE + eucommon.GAS_DELTA_WRITE},
+		{true, "get(bytes32)", [][]byte{first}, eucommon.GAS_READ + eucommon.GAS_ENCODE},
+		{true, "min(bytes32)", [][]byte{first}, eucommon.GAS_READ + eucommon.GAS_ENCODE},
+		{true, "max(bytes32)", [][]byte{first}, eucommon.GAS_READ + eucommon.GAS_ENCODE},
+	} {
+		input := crypto.Keccak256([]byte(op.signature))[:4]
+		for _, arg := range op.args {
+			input = append(input, arg...)
+		}
+
+		// The flat cost plus one unit of gas for every 32 bytes of data read or written.
+		_, ok, gas := handler.Call(caller.proxy, eucommon.CUMULATIVE_U256_HANDLER, input, Alice, 0, op.isReadOnly)
+		if !ok || gas < op.flat || gas > op.flat+16 {
+			t.Error("Wrong gas charged for", op.signature, gas, "the flat cost is", op.flat)
+		}
+	}
+
+	if _, _, gas := handler.Call(caller.proxy, eucommon.CUMULATIVE_U256_HANDLER, []byte{1, 2, 3, 4}, Alice, 0, false); gas != eucommon.GAS_CALL_UNKNOW {
+		t.Error("Expected the gas for an unknown call, got", gas)
+	}
+}
+
+func TestUint64CumulativeGas(t *testing.T) {
+	testEu := NewTestEU(Coinbase, Alice)
+	caller := newHandlerCaller(testEu, eucommon.CUMULATIVE_U64_HANDLER)
+	first, second := evmcommon.Hash{1}.Bytes(), evmcommon.Hash{2}.Bytes()
+
+	// Run a transaction first, so the EU has a job for the direct calls below.
+	if _, ok := caller.call(false, "new(bytes32,uint64,uint64)", first, int256Arg(0), int256Arg(100)); !ok {
+		t.Fatal("Failed to create the variable")
+	}
+
+	// Each variable has two counters, the updates check the bounds on both of them.
+	read, write := eucommon.GAS_CUMULATIVE_U64_READ, eucommon.GAS_CUMULATIVE_U64_WRITE
+	handler := testEu.eu.Api().(*apihandler.APIHandler).HandlerDict()[eucommon.CUMULATIVE_U64_HANDLER]
+	for _, op := range []struct {
+		isReadOnly bool
+		signature  string
+		args       [][]byte
+		flat       int64
+	}{
+		{false, "new(bytes32,uint64,uint64)", [][]byte{second, int256Arg(0), int256Arg(100)}, eucommon.GAS_DECODE*2 + write*2},
+		{false, "add(bytes32,uint64)", [][]byte{first, int256Arg(10)}, eucommon.GAS_DECODE + read*2 + write},
+		{false, "sub(bytes32,uint64)", [][]byte{first, int256Arg(5)}, eucommon.GAS_DECODE + read*2 + write},
+		{true, "get(bytes32)", [][]byte{first}, read*2 + eucommon.GAS_ENCODE},
+		{true, "min(bytes32)", [][]byte{first}, read*2 + eucommon.GAS_ENCODE},
+		{true, "max(bytes32)", [][]byte{first}, read*2 + eucommon.GAS_ENCODE},
+	} {
+		input := crypto.Keccak256([]byte(op.signature))[:4]
+		for _, arg := range op.args {
+			input = append(input, arg...)
+		}
+
+		_, ok, gas := handler.Call(caller.proxy, eucommon.CUMULATIVE_U64_HANDLER, input, Alice, 0, op.isReadOnly)
+		if !ok || gas < op.flat || gas > op.flat+16 {
+			t.Error("Wrong gas charged for", op.signature, gas, "the flat cost is", op.flat)
+		}
+	}
+}
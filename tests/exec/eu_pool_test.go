@@ -0,0 +1,127 @@
+/*
+ *   Copyright (c) 2025 Arcology Network
+
+ *   This program is free software: you can redistribute it and/or modify
+ *   it under the terms of the GNU General Public License as published by
+ *   the Free Software Foundation, either version 3 of the License, or
+ *   (at your option) any later version.
+
+ *   This program is distributed in the hope that it will be useful,
+ *   but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *   GNU General Public License for more details.
+
+ *   You should have received a copy of the GNU General Public License
+ *   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package exectest
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	commontype "github.com/arcology-network/common-lib/types"
+	eu "github.com/arcology-network/eu"
+	eucommon "github.com/arcology-network/eu/common"
+	univalue "github.com/arcology-network/storage-committer/type/univalue"
+)
+
+func TestEUPoolReuse(t *testing.T) {
+	testEu := NewTestEU(Coinbase, Alice, Bob)
+	pool := eucommon.NewEUPool(1)
+
+	paths, encoded := make([][]string, 3), make([][]byte, 3)
+	seqs := make([]*eucommon.JobSequence, 3)
+	for i := range seqs {
+		seqs[i] = newTestSeq(uint64(i+1), Alice, Bob, 0, 100, 1e6)
+		seqs[i].RunWithContext(context.Background(), testEu.config, testEu.eu.Api().Cascade(), pool, 0)
+
+		if err := seqs[i].Jobs[0].Results.Err; err != nil {
+			t.Error("Expected no error, got", err)
+		}
+
+		transitions := seqs[i].GetClearedTransition()
+		if len(transitions) == 0 {
+			t.Error("Expected some transitions")
+		}
+
+		for _, v := range transitions {
+			paths[i] = append(paths[i], *v.GetPath())
+		}
+		encoded[i] = univalue.Univalues(transitions).Encode()
+	}
+
+	// The later runs reuse the EU, the transitions exported by the earlier ones must stay intact.
+	for i, seq := range seqs {
+		transitions := seq.GetClearedTransition()
+		for j, v := range transitions {
+			if j >= len(paths[i]) || *v.GetPath() != paths[i][j] {
+				t.Fatalf("Sequence %d: the path of transition %d has changed to %v", i, j, *v.GetPath())
+			}
+		}
+
+		if len(transitions) != len(paths[i]) || !bytes.Equal(univalue.Univalues(transitions).Encode(), encoded[i]) {
+			t.Errorf("Sequence %d: the transitions have changed", i)
+		}
+	}
+}
+
+// The pooled EUs keep their statedbs, EVMs and handlers, so a pooled run allocates less than an unpooled one.
+func TestEUPoolAllocs(t *testing.T) {
+	testEu := NewTestEU(Coinbase, Alice, Bob)
+	api := testEu.eu.Api()
+
+	allocs := func(pool *eucommon.EUPool) float64 {
+		id := uint64(0)
+		return testing.AllocsPerRun(100, func() {
+			id++
+			seq := newTestSeq(id, Alice, Bob, 0, 100, 1e6)
+			seq.RunWithContext(context.Background(), testEu.config, api.Cascade(), pool, 0)
+		})
+	}
+
+	if pooled, unpooled := allocs(eucommon.NewEUPool(1)), allocs(nil); pooled >= unpooled {
+		t.Errorf("Expected fewer allocations with the pool, got %v pooled vs %v unpooled", pooled, unpooled)
+	}
+}
+
+// The block executor keeps its pool across the blocks, so a block allocates less than on a new executor.
+func TestBlockExecutorReusesEUs(t *testing.T) {
+	testEu := NewTestEU(Coinbase, Alice, Bob)
+	msgs := []*commontype.StandardMessage{
+		newTestSeq(1, Alice, Abby, 0, 100, 1e6).Jobs[0].StdMsg,
+		newTestSeq(2, Bob, Andy, 0, 100, 1e6).Jobs[0].StdMsg,
+	}
+
+	executor := eu.NewBlockExecutor(testEu.config, 2)
+	shared := testing.AllocsPerRun(20, func() {
+		if result, err := executor.Execute(context.Background(), msgs, testEu.eu.Api()); err != nil || len(result.Receipts) != 2 {
+			t.Fatal("Expected 2 receipts, got", result, err)
+		}
+	})
+
+	fresh := testing.AllocsPerRun(20, func() {
+		eu.NewBlockExecutor(testEu.config, 2).Execute(context.Background(), msgs, testEu.eu.Api())
+	})
+
+	if shared >= fresh {
+		t.Errorf("Expected fewer allocations on the same executor, got %v vs %v on new ones", shared, fresh)
+	}
+}
+
+func benchmarkTransfers(b *testing.B, pool *eucommon.EUPool) {
+	testEu := NewTestEU(Coinbase, Alice, Bob)
+	api := testEu.eu.Api()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		seq := newTestSeq(uint64(i+1), Alice, Bob, 0, 100, 1e6)
+		seq.RunWithContext(context.Background(), testEu.config, api.Cascade(), pool, 0)
+	}
+}
+
+func BenchmarkTransfersWithoutEUPool(b *testing.B) { benchmarkTransfers(b, nil) }
+func BenchmarkTransfersWithEUPool(b *testing.B)    { benchmarkTransfers(b, eucommon.NewEUPool(1)) }
@@ -0,0 +1,316 @@
+/*
+ *   Copyright (c) 2025 Arcology Network
+
+ *   This program is free software: you can redistribute it and/or modify
+ *   it under the terms of the GNU General Public License as published by
+ *   the Free Software Foundation, either version 3 of the License, or
+ *   (at your option) any later version.
+
+ *   This program is distributed in the hope that it will be useful,
+ *   but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *   GNU General Public License for more details.
+
+ *   You should have received a copy of the GNU General Public License
+ *   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package exectest
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arcology-network/common-lib/codec"
+	slice "github.com/arcology-network/common-lib/exp/slice"
+	commontype "github.com/arcology-network/common-lib/types"
+	eu "github.com/arcology-network/eu"
+	eucommon "github.com/arcology-network/eu/common"
+	ethimpl "github.com/arcology-network/eu/eth"
+	stgcommon "github.com/arcology-network/storage-committer/common"
+	noncommutative "github.com/arcology-network/storage-committer/type/noncommutative"
+	univalue "github.com/arcology-network/storage-committer/type/univalue"
+	evmcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	evmcore "github.com/ethereum/go-ethereum/core"
+)
+
+// An endless loop: JUMPDEST PUSH1 0x00 JUMP
+var endlessLoop = []byte{0x5b, 0x60, 0x00, 0x56}
+
+// SSTORE(0, SLOAD(0) + 1) STOP
+var counter = []byte{0x60, 0x00, 0x54, 0x60, 0x01, 0x01, 0x60, 0x00, 0x55, 0x00}
+
+// setTestCode creates a contract with the code in the cache of the test EU.
+func setTestCode(testEu *TestEu, name string, code []byte) evmcommon.Address {
+	contract := evmcommon.BytesToAddress([]byte(name))
+	statedb := ethimpl.NewImplStateDB(testEu.eu.Api())
+	statedb.PrepareFormer(evmcommon.Hash{}, evmcommon.Hash{}, 0)
+	statedb.CreateAccount(contract)
+	statedb.SetCode(contract, code)
+	return contract
+}
+
+// slotWrites returns the transitions of the first storage slot of the contract.
+func slotWrites(transitions []*univalue.Univalue, contract evmcommon.Address) []*univalue.Univalue {
+	suffix := hexutil.Encode(contract[:]) + "/storage/native/" + evmcommon.Hash{}.Hex()
+	return slice.CopyIf(transitions, func(_ int, v *univalue.Univalue) bool {
+		return strings.HasSuffix(*v.GetPath(), suffix) && v.Value() != nil
+	})
+}
+
+// slotValue decodes the value of a storage slot transition.
+func slotValue(v *univalue.Univalue) *big.Int {
+	return new(big.Int).SetBytes(v.Value().(*noncommutative.Bytes).Value().(codec.Bytes))
+}
+
+func newTestSeq(id uint64, from, to evmcommon.Address, nonce, value, gasLimit uint64) *eucommon.JobSequence {
+	msg := evmcore.NewMessage(from, &to, nonce, new(big.Int).SetUint64(value), gasLimit, big.NewInt(1), nil, nil, false)
+	return eucommon.NewJobSequence(id, []uint64{id}, []*evmcore.Message{&msg}, [][32]byte{{byte(id + 1)}}, nil)
+}
+
+func TestGenerationExecuteWithCancelledContext(t *testing.T) {
+	testEu := NewTestEU(Coinbase, Alice)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	gen := eu.NewGeneration(0, 2, []*eucommon.JobSequence{newTestSeq(1, Alice, Bob, 0, 100, 1e6)})
+	if transitions := gen.ExecuteWithContext(ctx, testEu.config, testEu.eu.Api()); len(transitions) != 0 {
+		t.Error("Expected no transitions, got", len(transitions))
+	}
+
+	if err := gen.At(0).Jobs[0].Results.Err; err != eucommon.ErrExecutionTimeout {
+		t.Error("Expected the timeout error, got", err)
+	}
+
+	// The skipped job still gets a failed receipt.
+	if receipt := gen.At(0).Jobs[0].Results.Receipt; receipt == nil || receipt.Status != 0 || receipt.GasUsed != 0 {
+		t.Error("Expected a failed receipt using no gas, got", receipt)
+	}
+}
+
+func TestGenerationExecuteWithJobTimeout(t *testing.T) {
+	testEu := NewTestEU(Coinbase, Alice, Bob)
+
+	contract := evmcommon.BytesToAddress([]byte("endless"))
+	statedb := ethimpl.NewImplStateDB(testEu.eu.Api())
+	statedb.PrepareFormer(evmcommon.Hash{}, evmcommon.Hash{}, 0)
+	statedb.CreateAccount(contract)
+	statedb.SetCode(contract, endlessLoop)
+
+	testEu.config.JobTimeout = 100 * time.Millisecond
+
+	gen := eu.NewGeneration(0, 2, []*eucommon.JobSequence{
+		newTestSeq(1, Alice, contract, 0, 0, 1e12), // Never finishes without the timeout.
+		newTestSeq(2, Bob, Alice, 0, 100, 1e6),
+	})
+
+	start := time.Now()
+	if transitions := gen.Execute(testEu.config, testEu.eu.Api()); len(transitions) == 0 {
+		t.Error("The transitions of the finished job should still be returned")
+	}
+
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Error("The endless loop wasn't cancelled in time:", elapsed)
+	}
+
+	if err := gen.At(0).Jobs[0].Results.Err; err != eucommon.ErrExecutionTimeout {
+		t.Error("Expected the timeout error, got", err)
+	}
+
+	if err := gen.At(1).Jobs[0].Results.Err; err != nil {
+		t.Error("Expected no error, got", err)
+	}
+}
+
+// The jobs run into the timeout, so they overlap. How many of them actually overlap depends on the scheduling, the
+// peak parallelism is only guaranteed to stay within [1, numThreads].
+func TestGenerationParallelism(t *testing.T) {
+	testEu := NewTestEU(Coinbase, Alice, Bob)
+
+	contract := evmcommon.BytesToAddress([]byte("endless"))
+	statedb := ethimpl.NewImplStateDB(testEu.eu.Api())
+	statedb.PrepareFormer(evmcommon.Hash{}, evmcommon.Hash{}, 0)
+	statedb.CreateAccount(contract)
+	statedb.SetCode(contract, endlessLoop)
+	testEu.config.JobTimeout = 50 * time.Millisecond
+
+	for _, numThreads := range []uint8{1, 4} {
+		seqs := make([]*eucommon.JobSequence, 8)
+		for i := range seqs {
+			seqs[i] = newTestSeq(uint64(i+1), Alice, contract, 0, 0, 1e12)
+		}
+
+		gen := eu.NewGeneration(0, numThreads, seqs)
+		gen.Execute(testEu.config, testEu.eu.Api())
+		if peak := gen.PeakParallelism(); peak < 1 || peak > int(numThreads) {
+			t.Errorf("Expected the peak parallelism within [1, %d], got %d", numThreads, peak)
+		}
+	}
+}
+
+// B depends on A but is listed first. B runs in the second wave, it reads A's write and overwrites it, so B's
+// transition has to come after A's for the final state to be right.
+func TestGenerationDependencyOrder(t *testing.T) {
+	testEu := NewTestEU(Coinbase, Alice, Bob)
+	contract := setTestCode(testEu, "counter", counter)
+
+	a := newTestSeq(1, Alice, contract, 0, 0, 1e6)
+	b := newTestSeq(2, Bob, contract, 0, 0, 1e6)
+	b.PreTxs = []uint64{1}
+
+	gen := eu.NewGeneration(0, 2, []*eucommon.JobSequence{b, a})
+	writes := slotWrites(gen.Execute(testEu.config, testEu.eu.Api()), contract)
+	if len(writes) != 2 {
+		t.Fatal("Expected 2 writes to the slot, got", len(writes))
+	}
+
+	if writes[0].GetTx() != 1 || writes[1].GetTx() != 2 {
+		t.Error("Expected A's write before B's, got", writes[0].GetTx(), writes[1].GetTx())
+	}
+
+	if v := slotValue(writes[1]); v.Uint64() != 2 {
+		t.Error("B should have read A's write, got", v)
+	}
+
+	if err := b.Jobs[0].Results.Err; err != nil {
+		t.Error("Expected no error, got", err)
+	}
+}
+
+// A and C increment the same counter, C loses the conflict. D depends on C and E on D, they are both skipped instead of
+// running against the state C never committed. B depends on A and runs normally.
+func TestGenerationSkipsDependentsOfFailedSequences(t *testing.T) {
+	testEu := NewTestEU(Coinbase, Alice, Bob, Abby, Abu, Andy)
+	contract := setTestCode(testEu, "counter", counter)
+
+	a := newTestSeq(1, Alice, contract, 0, 0, 1e6)
+	c := newTestSeq(2, Bob, contract, 0, 0, 1e6)
+	d := newTestSeq(3, Abby, contract, 0, 0, 1e6)
+	e := newTestSeq(4, Abu, contract, 0, 0, 1e6)
+	b := newTestSeq(5, Andy, contract, 0, 0, 1e6)
+	d.PreTxs, e.PreTxs, b.PreTxs = []uint64{2}, []uint64{3}, []uint64{1}
+
+	writes := slotWrites(eu.NewGeneration(0, 2, []*eucommon.JobSequence{a, b, c, d, e}).Execute(testEu.config, testEu.eu.Api()), contract)
+	if err := c.Jobs[0].Results.Err; err == nil || err.Error() != stgcommon.WARN_ACCESS_CONFLICT {
+		t.Fatal("Expected C to lose the conflict, got", err)
+	}
+
+	for name, seq := range map[string]*eucommon.JobSequence{"D": d, "E": e} {
+		result := seq.Jobs[0].Results
+		if result.Err != eu.ErrPrerequisiteFailed {
+			t.Errorf("Expected %s to be skipped, got %v", name, result.Err)
+		}
+
+		if result.Receipt == nil || result.Receipt.Status != 0 || result.Receipt.GasUsed != 0 {
+			t.Errorf("Expected %s to have a failed receipt using no gas", name)
+		}
+	}
+
+	if err := b.Jobs[0].Results.Err; err != nil {
+		t.Error("Expected B to succeed, got", err)
+	}
+
+	// Only A and B have written the slot.
+	if len(writes) != 2 || writes[0].GetTx() != 1 || writes[1].GetTx() != 5 {
+		t.Fatal("Expected the writes of A and B only, got", len(writes))
+	}
+
+	if v := slotValue(writes[1]); v.Uint64() != 2 {
+		t.Error("B should have read A's write, got", v)
+	}
+}
+
+// All the sequences increment the same counter. In the retry mode, one more of them wins in each round, so they all
+// succeed eventually and the transitions come out in the order of the rounds regardless of the number of threads.
+func TestGenerationRetriesAreDeterministic(t *testing.T) {
+	var expected []*univalue.Univalue
+	for _, numThreads := range []uint8{1, 2, 8} {
+		testEu := NewTestEU(Coinbase, Alice, Bob, Abby, Abu)
+		contract := setTestCode(testEu, "counter", counter)
+
+		gen := eu.NewGeneration(0, numThreads, []*eucommon.JobSequence{
+			newTestSeq(4, Abu, contract, 0, 0, 1e6),
+			newTestSeq(3, Abby, contract, 0, 0, 1e6),
+			newTestSeq(2, Bob, contract, 0, 0, 1e6),
+			newTestSeq(1, Alice, contract, 0, 0, 1e6),
+		}).SetMaxRetries(4)
+
+		transitions := gen.Execute(testEu.config, testEu.eu.Api())
+		for _, seq := range gen.JobSeqs() {
+			if err := seq.Jobs[0].Results.Err; err != nil && err.Error() == stgcommon.WARN_ACCESS_CONFLICT {
+				t.Error("Sequence", seq.ID, "is still in conflict on", numThreads, "threads")
+			}
+		}
+
+		// The lowest ID wins in each round.
+		writes := slotWrites(transitions, contract)
+		if len(writes) != 4 {
+			t.Fatal("Expected 4 writes to the counter, got", len(writes))
+		}
+
+		for i, v := range writes {
+			if v.GetTx() != uint64(i+1) || slotValue(v).Uint64() != uint64(i+1) {
+				t.Errorf("Write %d on %d threads: expected tx %d to set %d, got tx %d and %v", i, numThreads, i+1, i+1, v.GetTx(), slotValue(v))
+			}
+		}
+
+		if expected == nil {
+			expected = transitions
+			continue
+		}
+
+		if len(transitions) != len(expected) {
+			t.Fatal("Expected", len(expected), "transitions on", numThreads, "threads, got", len(transitions))
+		}
+
+		for i := range transitions {
+			if !transitions[i].Equal(expected[i]) {
+				t.Error("Transition", i, "on", numThreads, "threads differs:", *transitions[i].GetPath(), *expected[i].GetPath())
+			}
+		}
+	}
+}
+
+// More than 255 messages, the thread count declared by the caller bounds the workers.
+func TestGenerationFromMsgsRespectsThreads(t *testing.T) {
+	testEu := NewTestEU(Coinbase, Alice, Bob)
+
+	// Run a transaction first, so the API has an EU to derive the sub transaction hashes from.
+	msg := evmcore.NewMessage(Bob, &Abby, 0, big.NewInt(1), 1e6, big.NewInt(1), nil, nil, false)
+	job := &eucommon.Job{StdMsg: &commontype.StandardMessage{ID: 1, TxHash: [32]byte{1}, Native: &msg}}
+	if _, _, err := testEu.eu.Run(job, eucommon.NewEVMBlockContext(testEu.config), eucommon.NewEVMTxContext(msg)); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := make([]*evmcore.Message, 300)
+	for i := range msgs {
+		to := evmcommon.BigToAddress(big.NewInt(int64(i + 1000)))
+		msg := evmcore.NewMessage(Alice, &to, 0, big.NewInt(1), 1e6, big.NewInt(1), nil, nil, false)
+		msgs[i] = &msg
+	}
+
+	gen := eu.NewGenerationFromMsgs(0, 2, msgs, testEu.eu.Api())
+	if gen.NumThreads() != 2 || gen.Length() != 300 {
+		t.Fatal("Expected 300 sequences on 2 threads, got", gen.Length(), gen.NumThreads())
+	}
+
+	gen.Execute(testEu.config, testEu.eu.Api())
+	for i, seq := range gen.JobSeqs() {
+		if seq.Jobs[0].Results.Receipt == nil {
+			t.Fatal("The job hasn't been executed", i)
+		}
+	}
+
+	if peak := gen.PeakParallelism(); peak < 1 || peak > 2 {
+		t.Error("Expected the peak parallelism within [1, 2], got", peak)
+	}
+
+	if gen := eu.NewGenerationFromMsgs(0, 0, msgs[:1], testEu.eu.Api()); gen.NumThreads() != 1 {
+		t.Error("Expected at least one thread, got", gen.NumThreads())
+	}
+}
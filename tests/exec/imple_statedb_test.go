@@ -19,12 +19,14 @@ package exectest
 
 import (
 	"bytes"
+	"slices"
 	"testing"
 
 	"github.com/arcology-network/common-lib/exp/mempool"
 	eth "github.com/arcology-network/eu/eth"
 	cache "github.com/arcology-network/storage-committer/storage/cache"
 	stgcomm "github.com/arcology-network/storage-committer/storage/committer"
+	univalue "github.com/arcology-network/storage-committer/type/univalue"
 	evmcommon "github.com/ethereum/go-ethereum/common"
 
 	apihandler "github.com/arcology-network/eu/apihandler"
@@ -241,3 +243,56 @@ func TestStateDBV2GetNonexistStorageState(t *testing.T) {
 // 		t.Error("Error: Shouldn't be not found")
 // 	}
 // }
+
+func TestStateDBForEachStorage(t *testing.T) {
+	db := chooseDataStore()
+	api := apihandler.NewAPIHandler(mempool.NewMempool[*cache.WriteCache](16, 1, func() *cache.WriteCache {
+		return cache.NewWriteCache(db, 32, 1)
+	}, func(cache *cache.WriteCache) { cache.Clear() }))
+
+	account := evmcommon.BytesToAddress([]byte{201, 202, 203, 204, 205})
+	ethStatedb := eth.NewImplStateDB(api)
+	ethStatedb.PrepareFormer(evmcommon.Hash{}, evmcommon.Hash{}, 1)
+	ethStatedb.CreateAccount(account)
+	ethStatedb.SetState(account, evmcommon.Hash{1}, evmcommon.Hash{11})
+	ethStatedb.SetState(account, evmcommon.Hash{2}, evmcommon.Hash{22})
+	_, transitions := api.WriteCache().(*cache.WriteCache).ExportAll()
+
+	committer := stgcomm.NewStateCommitter(db, nil)
+	committer.Import(transitions)
+	committer.Precommit([]uint64{1})
+	committer.Commit(20)
+	api.WriteCache().(*cache.WriteCache).Clear()
+
+	// The committed slots and the new one.
+	ethStatedb = eth.NewImplStateDB(api)
+	ethStatedb.PrepareFormer(evmcommon.Hash{}, evmcommon.Hash{}, 2)
+	ethStatedb.SetState(account, evmcommon.Hash{3}, evmcommon.Hash{33})
+
+	visited := map[evmcommon.Hash]evmcommon.Hash{}
+	ethStatedb.ForEachStorage(account, func(key, value evmcommon.Hash) bool {
+		visited[key] = value
+		return true
+	})
+
+	if len(visited) != 3 || visited[evmcommon.Hash{1}] != (evmcommon.Hash{11}) || visited[evmcommon.Hash{3}] != (evmcommon.Hash{33}) {
+		t.Error("Wrong storage slots", visited)
+	}
+
+	counter := 0
+	ethStatedb.ForEachStorage(account, func(key, value evmcommon.Hash) bool {
+		counter++
+		return false
+	})
+
+	if counter != 1 {
+		t.Error("The walk should stop after the first slot, visited", counter)
+	}
+
+	// The storage path must be in the read accesses.
+	accesses := univalue.Univalues(api.WriteCache().(*cache.WriteCache).Export()).To(univalue.IPAccess{})
+	storagePath := (&eth.EthPathBuilder{}).StorageRootPath(account)
+	if !slices.ContainsFunc(accesses, func(v *univalue.Univalue) bool { return *v.GetPath() == storagePath }) {
+		t.Error("Expected the storage path to be recorded as an access")
+	}
+}
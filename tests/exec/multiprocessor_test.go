@@ -0,0 +1,117 @@
+/*
+ *   Copyright (c) 2025 Arcology Network
+
+ *   This program is free software: you can redistribute it and/or modify
+ *   it under the terms of the GNU General Public License as published by
+ *   the Free Software Foundation, either version 3 of the License, or
+ *   (at your option) any later version.
+
+ *   This program is distributed in the hope that it will be useful,
+ *   but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *   GNU General Public License for more details.
+
+ *   You should have received a copy of the GNU General Public License
+ *   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package exectest
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	eu "github.com/arcology-network/eu"
+	apimultiprocess "github.com/arcology-network/eu/apihandler/multiprocess"
+	eucommon "github.com/arcology-network/eu/common"
+	ethimpl "github.com/arcology-network/eu/eth"
+	noncommutative "github.com/arcology-network/storage-committer/type/noncommutative"
+	evmcommon "github.com/ethereum/go-ethereum/common"
+	evmcore "github.com/ethereum/go-ethereum/core"
+)
+
+var (
+	slotWriter = []byte{0x60, 0x01, 0x60, 0x00, 0x55, 0x00} // SSTORE(0, 1) STOP
+	reverter   = []byte{0x60, 0x00, 0x60, 0x00, 0xfd}       // REVERT(0, 0)
+)
+
+// newMultiprocessor creates the job container in the proxy contract and pushes a job calling each of the targets with the gas limit.
+func newMultiprocessor(t *testing.T, testEu *TestEu, gasLimit int64, targets ...evmcommon.Address) *handlerCaller {
+	caller := newHandlerCaller(testEu, eucommon.MULTIPROCESS_HANDLER)
+	if _, ok := caller.call(false, "new(uint8,bool)", packArgs([]string{"uint8", "bool"}, noncommutative.BYTES, false)); !ok {
+		t.Fatal("Failed to create the job container")
+	}
+
+	for i, target := range targets {
+		job := packArgs([]string{"uint256", "uint256", "address", "bytes"}, big.NewInt(gasLimit), big.NewInt(0), target, []byte{})
+		if _, ok := caller.call(false, "eval(bytes)", evalArg("setByKey(bytes,bytes)", []string{"bytes", "bytes"}, []byte{byte(i)}, job)); !ok {
+			t.Fatal("Failed to push the job", i)
+		}
+	}
+	return caller
+}
+
+// The first two jobs write the same slot, the second one loses the conflict. The third one reverts but isn't in any conflict.
+func TestMultiprocessorRunDetailed(t *testing.T) {
+	testEu := NewTestEU(Coinbase, Alice)
+
+	writer, failing := evmcommon.BytesToAddress([]byte("writer")), evmcommon.BytesToAddress([]byte("reverter"))
+	statedb := ethimpl.NewImplStateDB(testEu.eu.Api())
+	statedb.PrepareFormer(evmcommon.Hash{}, evmcommon.Hash{}, 0)
+	statedb.CreateAccount(writer)
+	statedb.SetCode(writer, slotWriter)
+	statedb.CreateAccount(failing)
+	statedb.SetCode(failing, reverter)
+
+	caller := newMultiprocessor(t, testEu, 1e5, writer, writer, failing)
+	ret, ok := caller.call(false, "runDetailed(bytes)", packArgs([]string{"bytes"}, packArgs([]string{"uint256"}, big.NewInt(2))))
+	if !ok {
+		t.Fatal("Failed to run the jobs")
+	}
+
+	results, err := apimultiprocess.DecodeJobResults(ret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 3 {
+		t.Fatal("Expected 3 results, got", len(results))
+	}
+
+	for i, expected := range []struct{ success, conflicted bool }{
+		{true, false},  // The winner
+		{false, true},  // Lost the conflict
+		{false, false}, // Reverted, not a conflict
+	} {
+		if results[i].Success != expected.success || results[i].Conflicted != expected.conflicted {
+			t.Errorf("Job %d: expected (success, conflicted) = (%v, %v), got (%v, %v)", i, expected.success, expected.conflicted, results[i].Success, results[i].Conflicted)
+		}
+
+		if results[i].GasUsed == 0 {
+			t.Error("Expected the gas used of job", i)
+		}
+	}
+}
+
+// The sub processes are bound to the context of the parent job, so a sub process that never finishes is cancelled when
+// the parent job times out.
+func TestMultiprocessorParentTimeout(t *testing.T) {
+	testEu := NewTestEU(Coinbase, Alice)
+	testEu.config.JobTimeout = 100 * time.Millisecond
+
+	caller := newMultiprocessor(t, testEu, 1e12, setTestCode(testEu, "endless", endlessLoop)) // Never finishes without the timeout.
+	id := caller.txID + 1
+	msg := evmcore.NewMessage(Alice, &caller.proxy, 0, new(big.Int), 1e13, big.NewInt(1), caller.calldata(false, "runDetailed(bytes)", packArgs([]string{"bytes"}, packArgs([]string{"uint256"}, big.NewInt(1)))), nil, false)
+	gen := eu.NewGeneration(0, 1, []*eucommon.JobSequence{eucommon.NewJobSequence(id, []uint64{id}, []*evmcore.Message{&msg}, [][32]byte{{byte(id + 1)}}, nil)})
+
+	start := time.Now()
+	gen.Execute(testEu.config, testEu.eu.Api())
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Error("The sub process wasn't cancelled in time:", elapsed)
+	}
+
+	if err := gen.At(0).Jobs[0].Results.Err; err != eucommon.ErrExecutionTimeout {
+		t.Error("Expected the timeout error, got", err)
+	}
+}
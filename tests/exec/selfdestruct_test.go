@@ -0,0 +1,170 @@
+/*
+ *   Copyright (c) 2025 Arcology Network
+
+ *   This program is free software: you can redistribute it and/or modify
+ *   it under the terms of the GNU General Public License as published by
+ *   the Free Software Foundation, either version 3 of the License, or
+ *   (at your option) any later version.
+
+ *   This program is distributed in the hope that it will be useful,
+ *   but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *   GNU General Public License for more details.
+
+ *   You should have received a copy of the GNU General Public License
+ *   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package exectest
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	eu "github.com/arcology-network/eu"
+	eucommon "github.com/arcology-network/eu/common"
+	ethimpl "github.com/arcology-network/eu/eth"
+	evmcommon "github.com/ethereum/go-ethereum/common"
+	evmcore "github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+)
+
+// selfDestruct does what the Cancun SELFDESTRUCT opcode does to the statedb.
+func selfDestruct(statedb *ethimpl.ImplStateDB, addr, beneficiary evmcommon.Address) {
+	balance := statedb.GetBalance(addr).Clone()
+	statedb.SubBalance(addr, balance)
+	statedb.AddBalance(beneficiary, balance)
+	statedb.Selfdestruct6780(addr)
+}
+
+func TestSelfDestructInCreatingTx(t *testing.T) {
+	testEu := NewTestEU(Coinbase, Alice, Bob)
+	statedb := ethimpl.NewImplStateDB(testEu.eu.Api())
+	statedb.PrepareFormer(evmcommon.Hash{}, evmcommon.Hash{}, 1)
+
+	contract := evmcommon.BytesToAddress([]byte("contract"))
+	balance := statedb.GetBalance(Bob).Clone()
+
+	statedb.CreateAccount(contract)
+	statedb.SetCode(contract, []byte{0x60, 0x00})
+	statedb.SetState(contract, evmcommon.Hash{1}, evmcommon.Hash{2})
+	statedb.AddBalance(contract, uint256.NewInt(100))
+
+	selfDestruct(statedb, contract, Bob)
+	if !statedb.HasSelfDestructed(contract) || !statedb.Exist(contract) {
+		t.Error("The account should stay until the end of the transaction")
+	}
+
+	statedb.Finalise()
+	if statedb.Exist(contract) || len(statedb.GetCode(contract)) != 0 || statedb.GetState(contract, evmcommon.Hash{1}) != (evmcommon.Hash{}) {
+		t.Error("Expected the account to be removed")
+	}
+
+	if v := statedb.GetBalance(Bob); !v.Eq(new(uint256.Int).Add(balance, uint256.NewInt(100))) {
+		t.Error("Expected the beneficiary to receive the balance, got", v)
+	}
+}
+
+// Under EIP-6780, the accounts created in the previous transactions only lose their balance.
+func TestSelfDestructExistingAccount(t *testing.T) {
+	testEu := NewTestEU(Coinbase, Alice, Bob)
+	contract := evmcommon.BytesToAddress([]byte("contract"))
+
+	statedb := ethimpl.NewImplStateDB(testEu.eu.Api())
+	statedb.PrepareFormer(evmcommon.Hash{}, evmcommon.Hash{}, 1)
+	statedb.CreateAccount(contract)
+	statedb.SetCode(contract, []byte{0x60, 0x00})
+	statedb.AddBalance(contract, uint256.NewInt(100))
+
+	statedb.PrepareFormer(evmcommon.Hash{}, evmcommon.Hash{}, 2)
+	selfDestruct(statedb, contract, Bob)
+	statedb.Finalise()
+
+	if statedb.HasSelfDestructed(contract) || !statedb.Exist(contract) || len(statedb.GetCode(contract)) == 0 {
+		t.Error("The account shouldn't be removed")
+	}
+
+	if v := statedb.GetBalance(contract); !v.IsZero() {
+		t.Error("Expected the balance to be moved, got", v)
+	}
+}
+
+// An account created in a previous transaction and destructing to itself keeps both the account and the balance.
+func TestSelfDestructExistingAccountToItself(t *testing.T) {
+	testEu := NewTestEU(Coinbase, Alice, Bob)
+	contract := evmcommon.BytesToAddress([]byte("contract"))
+
+	statedb := ethimpl.NewImplStateDB(testEu.eu.Api())
+	statedb.PrepareFormer(evmcommon.Hash{}, evmcommon.Hash{}, 1)
+	statedb.CreateAccount(contract)
+	statedb.SetCode(contract, []byte{0x60, 0x00})
+	statedb.AddBalance(contract, uint256.NewInt(100))
+
+	statedb.PrepareFormer(evmcommon.Hash{}, evmcommon.Hash{}, 2)
+	selfDestruct(statedb, contract, contract)
+	statedb.Finalise()
+
+	if statedb.HasSelfDestructed(contract) || !statedb.Exist(contract) || len(statedb.GetCode(contract)) == 0 {
+		t.Error("The account shouldn't be removed")
+	}
+
+	if v := statedb.GetBalance(contract); !v.Eq(uint256.NewInt(100)) {
+		t.Error("Expected the balance to be kept, got", v)
+	}
+}
+
+func TestSelfDestructRevert(t *testing.T) {
+	testEu := NewTestEU(Coinbase, Alice, Bob)
+	statedb := ethimpl.NewImplStateDB(testEu.eu.Api())
+	statedb.PrepareFormer(evmcommon.Hash{}, evmcommon.Hash{}, 1)
+
+	contract := evmcommon.BytesToAddress([]byte("contract"))
+	statedb.CreateAccount(contract)
+	statedb.AddBalance(contract, uint256.NewInt(100))
+
+	snapshot := statedb.Snapshot()
+	selfDestruct(statedb, contract, Bob)
+	statedb.RevertToSnapshot(snapshot)
+	statedb.Finalise()
+
+	if statedb.HasSelfDestructed(contract) || !statedb.Exist(contract) {
+		t.Error("The self destruct should be reverted")
+	}
+
+	if v := statedb.GetBalance(contract); !v.Eq(uint256.NewInt(100)) {
+		t.Error("Expected the balance to be reverted, got", v)
+	}
+}
+
+// Alice deploys a contract destructing itself in the constructor, while Bob sends some funds to the same address
+// in parallel. Both touch the destructed account, so Bob's transaction is flagged as conflicting.
+func TestSelfDestructConflict(t *testing.T) {
+	testEu := NewTestEU(Coinbase, Alice, Bob)
+	contract := crypto.CreateAddress(Alice, 0)
+
+	initCode := append([]byte{0x73}, Bob.Bytes()...) // PUSH20 Bob
+	initCode = append(initCode, 0xff)                // SELFDESTRUCT(Bob)
+
+	deploy := evmcore.NewMessage(Alice, nil, 0, big.NewInt(100), 1e6, big.NewInt(1), initCode, nil, false)
+	jobSeqs := []*eucommon.JobSequence{
+		eucommon.NewJobSequence(1, []uint64{1}, []*evmcore.Message{&deploy}, [][32]byte{{1}}, nil),
+		newTestSeq(2, Bob, contract, 0, 100, 1e6),
+	}
+
+	gen := eu.NewGeneration(0, 2, jobSeqs)
+	gen.ExecuteWithContext(context.Background(), testEu.config, testEu.eu.Api())
+
+	if receipt := gen.At(0).Jobs[0].Results.Receipt; receipt == nil || receipt.Status != 1 || receipt.ContractAddress != contract {
+		t.Fatal("The deployment should succeed")
+	}
+
+	if err := gen.At(0).Jobs[0].Results.Err; err != nil {
+		t.Error("The deployment shouldn't conflict, got", err)
+	}
+
+	if err := gen.At(1).Jobs[0].Results.Err; err == nil {
+		t.Error("The transfer to the destructed account should conflict")
+	}
+}
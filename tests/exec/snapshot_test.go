@@ -0,0 +1,155 @@
+/*
+ *   Copyright (c) 2025 Arcology Network
+
+ *   This program is free software: you can redistribute it and/or modify
+ *   it under the terms of the GNU General Public License as published by
+ *   the Free Software Foundation, either version 3 of the License, or
+ *   (at your option) any later version.
+
+ *   This program is distributed in the hope that it will be useful,
+ *   but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *   GNU General Public License for more details.
+
+ *   You should have received a copy of the GNU General Public License
+ *   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package exectest
+
+import (
+	"math/big"
+	"testing"
+
+	commontype "github.com/arcology-network/common-lib/types"
+	eucommon "github.com/arcology-network/eu/common"
+	ethimpl "github.com/arcology-network/eu/eth"
+	evmcommon "github.com/ethereum/go-ethereum/common"
+	evmcore "github.com/ethereum/go-ethereum/core"
+	evmtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/holiman/uint256"
+)
+
+func TestStateDBSnapshotRevert(t *testing.T) {
+	testEu := NewTestEU(Coinbase, Alice, Bob)
+	statedb := ethimpl.NewImplStateDB(testEu.eu.Api())
+	statedb.PrepareFormer(evmcommon.Hash{1}, evmcommon.Hash{}, 1)
+
+	contract := evmcommon.BytesToAddress([]byte("contract"))
+	slot1, slot2 := evmcommon.Hash{1}, evmcommon.Hash{2}
+
+	statedb.SetState(Alice, slot1, evmcommon.Hash{11})
+	balance := statedb.GetBalance(Bob).Clone()
+
+	snapshot := statedb.Snapshot()
+	statedb.SetState(Alice, slot1, evmcommon.Hash{22})
+	statedb.SetState(Alice, slot2, evmcommon.Hash{33})
+	statedb.AddBalance(Bob, uint256.NewInt(100))
+	statedb.CreateAccount(contract)
+	statedb.SetCode(contract, []byte{0x60, 0x00})
+	statedb.SetTransientState(Alice, slot1, evmcommon.Hash{44})
+	statedb.AddRefund(10)
+	statedb.AddLog(&evmtypes.Log{Address: Alice})
+
+	statedb.RevertToSnapshot(snapshot)
+
+	if v := statedb.GetState(Alice, slot1); v != (evmcommon.Hash{11}) {
+		t.Error("Expected the storage to be reverted, got", v)
+	}
+
+	if v := statedb.GetState(Alice, slot2); v != (evmcommon.Hash{}) {
+		t.Error("Expected the new storage slot to be removed, got", v)
+	}
+
+	if v := statedb.GetBalance(Bob); !v.Eq(balance) {
+		t.Error("Expected the balance to be reverted, got", v)
+	}
+
+	if statedb.Exist(contract) || len(statedb.GetCode(contract)) != 0 {
+		t.Error("Expected the new account to be removed")
+	}
+
+	if v := statedb.GetTransientState(Alice, slot1); v != (evmcommon.Hash{}) {
+		t.Error("Expected the transient storage to be reverted, got", v)
+	}
+
+	if statedb.GetRefund() != 0 || len(statedb.GetLogs(evmcommon.Hash{1})) != 0 {
+		t.Error("Expected the refund and the logs to be reverted")
+	}
+}
+
+func TestStateDBNestedSnapshots(t *testing.T) {
+	testEu := NewTestEU(Coinbase, Alice, Bob)
+	statedb := ethimpl.NewImplStateDB(testEu.eu.Api())
+	statedb.PrepareFormer(evmcommon.Hash{1}, evmcommon.Hash{}, 1)
+
+	slot := evmcommon.Hash{1}
+	outer := statedb.Snapshot()
+	statedb.SetState(Alice, slot, evmcommon.Hash{1})
+
+	inner := statedb.Snapshot()
+	statedb.SetState(Alice, slot, evmcommon.Hash{2})
+	statedb.AddLog(&evmtypes.Log{Address: Alice})
+
+	statedb.RevertToSnapshot(inner)
+	if v := statedb.GetState(Alice, slot); v != (evmcommon.Hash{1}) {
+		t.Error("Expected the outer change to stay, got", v)
+	}
+
+	statedb.RevertToSnapshot(outer)
+	if v := statedb.GetState(Alice, slot); v != (evmcommon.Hash{}) {
+		t.Error("Expected the slot to be empty, got", v)
+	}
+}
+
+// The caller calls the callee, which writes 42 into slot 0 then reverts. The caller catches the failure, stores the
+// call result into slot 1 and 7 into slot 0, like a try/catch block in Solidity.
+func TestTryCatchRevertsInnerCall(t *testing.T) {
+	testEu := NewTestEU(Coinbase, Alice, Bob)
+	caller, callee := evmcommon.BytesToAddress([]byte("caller")), evmcommon.BytesToAddress([]byte("callee"))
+
+	calleeCode := []byte{
+		0x60, 0x2a, 0x60, 0x00, 0x55, // SSTORE(0, 42)
+		0x60, 0x00, 0x60, 0x00, 0xfd, // REVERT(0, 0)
+	}
+
+	callerCode := append([]byte{
+		0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, // retSize, retOffset, argsSize, argsOffset, value
+		0x73, // PUSH20 callee
+	}, callee.Bytes()...)
+	callerCode = append(callerCode,
+		0x5a, 0xf1, // CALL(GAS, callee, ...)
+		0x60, 0x01, 0x55, // SSTORE(1, success)
+		0x60, 0x07, 0x60, 0x00, 0x55, // SSTORE(0, 7)
+		0x00, // STOP
+	)
+
+	statedb := ethimpl.NewImplStateDB(testEu.eu.Api())
+	statedb.PrepareFormer(evmcommon.Hash{}, evmcommon.Hash{}, 0)
+	statedb.CreateAccount(caller)
+	statedb.SetCode(caller, callerCode)
+	statedb.CreateAccount(callee)
+	statedb.SetCode(callee, calleeCode)
+
+	msg := evmcore.NewMessage(Alice, &caller, 0, new(big.Int), 1e6, big.NewInt(1), nil, nil, false)
+	job := &eucommon.Job{StdMsg: &commontype.StandardMessage{ID: 1, TxHash: [32]byte{1}, Native: &msg}}
+
+	receipt, result, err := testEu.eu.Run(job, eucommon.NewEVMBlockContext(testEu.config), eucommon.NewEVMTxContext(msg))
+	if err != nil || result.Err != nil || receipt.Status != 1 {
+		t.Fatal("The outer call should succeed", err, result.Err)
+	}
+
+	statedb = ethimpl.NewImplStateDB(testEu.eu.Api())
+	statedb.PrepareFormer(evmcommon.Hash{}, evmcommon.Hash{}, 2)
+	if v := statedb.GetState(callee, evmcommon.Hash{}); v != (evmcommon.Hash{}) {
+		t.Error("The write in the reverted call should be undone, got", v)
+	}
+
+	if v := statedb.GetState(caller, evmcommon.BigToHash(big.NewInt(1))); v != (evmcommon.Hash{}) {
+		t.Error("The inner call should have failed, got", v)
+	}
+
+	if v := statedb.GetState(caller, evmcommon.Hash{}); v != evmcommon.BigToHash(big.NewInt(7)) {
+		t.Error("The caller should continue after the failed call, got", v)
+	}
+}
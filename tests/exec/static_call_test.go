@@ -0,0 +1,244 @@
+/*
+ *   Copyright (c) 2025 Arcology Network
+
+ *   This program is free software: you can redistribute it and/or modify
+ *   it under the terms of the GNU General Public License as published by
+ *   the Free Software Foundation, either version 3 of the License, or
+ *   (at your option) any later version.
+
+ *   This program is distributed in the hope that it will be useful,
+ *   but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *   GNU General Public License for more details.
+
+ *   You should have received a copy of the GNU General Public License
+ *   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package exectest
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	commontype "github.com/arcology-network/common-lib/types"
+	apicontainer "github.com/arcology-network/eu/apihandler/container"
+	eucommon "github.com/arcology-network/eu/common"
+	"github.com/arcology-network/storage-committer/type/commutative"
+	noncommutative "github.com/arcology-network/storage-committer/type/noncommutative"
+	evmcommon "github.com/ethereum/go-ethereum/common"
+	evmcore "github.com/ethereum/go-ethereum/core"
+)
+
+// newComparatorTarget creates a container of int256s with a comparator, holding 5 under the key. Every mutating
+// selector of the containers can succeed on it.
+func newComparatorTarget(t *testing.T, key []byte) *handlerCaller {
+	caller := newHandlerCaller(NewTestEU(Coinbase, Alice), eucommon.BYTES_HANDLER)
+	if _, ok := caller.call(false, "newWithComparator(uint8,bool,uint8)", packArgs([]string{"uint8", "bool", "uint8"}, noncommutative.BYTES, false, apicontainer.CMP_INT256)); !ok {
+		t.Fatal("Failed to create the container")
+	}
+
+	if _, ok := caller.call(false, "eval(bytes)", evalArg("setByKey(bytes,bytes)", []string{"bytes", "bytes"}, key, int256Arg(5))); !ok {
+		t.Fatal("Failed to set")
+	}
+	return caller
+}
+
+// deployCalling deploys a contract whose constructor sends the data to the handler in a STATICCALL or a CALL, the
+// deployment fails if the call fails. Some of the runtime functions can only be called from a constructor.
+func deployCalling(isStatic bool, handler [20]byte, data []byte) bool {
+	call := []byte{0x60, 0x00, 0x60, 0x00, 0x61, byte(len(data) >> 8), byte(len(data)), 0x60, 0x00} // retSize, retOffset, argsSize, argsOffset
+	if !isStatic {
+		call = append(call, 0x60, 0x00) // value
+	}
+	call = append(append(call, 0x73), handler[:]...) // PUSH20 handler
+	call = append(call, 0x5a, 0xfa)                  // STATICCALL(GAS, handler, ...)
+	if !isStatic {
+		call[len(call)-1] = 0xf1 // CALL(GAS, handler, ...)
+	}
+
+	ok := byte(9 + len(call) + 8) // The JUMPDEST after the revert
+	offset := int(ok) + 1 + 5     // The data after the code
+
+	// CODECOPY(0, offset, size) the data into the memory
+	code := []byte{0x61, byte(len(data) >> 8), byte(len(data)), 0x61, byte(offset >> 8), byte(offset), 0x60, 0x00, 0x39}
+	code = append(append(code, call...),
+		0x60, ok, 0x57, // JUMPI(ok)
+		0x60, 0x00, 0x60, 0x00, 0xfd, // REVERT(0, 0)
+		0x5b,                         // ok:
+		0x60, 0x00, 0x60, 0x00, 0xf3, // RETURN(0, 0)
+	)
+	code = append(code, data...)
+
+	testEu := NewTestEU(Coinbase, Alice)
+	msg := evmcore.NewMessage(Alice, nil, 0, new(big.Int), 1e6, big.NewInt(1), code, nil, false)
+	job := &eucommon.Job{StdMsg: &commontype.StandardMessage{ID: 1, TxHash: [32]byte{1}, Native: &msg}}
+
+	receipt, _, err := testEu.eu.Run(job, eucommon.NewEVMBlockContext(testEu.config), eucommon.NewEVMTxContext(msg))
+	return err == nil && receipt.Status == 1
+}
+
+// Every state-mutating selector of the handlers has to fail in a STATICCALL, while the same call succeeds in a CALL.
+// The read-only selectors still work in a STATICCALL.
+func TestStaticCallRejectsMutations(t *testing.T) {
+	key := []byte("k")
+	for _, arg := range [][]byte{
+		evalArg("setByKey(bytes,bytes)", []string{"bytes", "bytes"}, key, int256Arg(6)),
+		evalArg("delByKey(bytes)", []string{"bytes"}, key),
+		evalArg("resetByKey(bytes)", []string{"bytes"}, key),
+		evalArg("resetByInd(uint256)", []string{"uint256"}, int256Arg(0)),
+		evalArg("delLast()", nil),
+		evalArg("clear()", nil),
+		evalArg("clearCommitted()", nil),
+		evalArg("setBatch(bytes[],bytes[])", []string{"bytes[]", "bytes[]"}, [][]byte{key}, [][]byte{int256Arg(6)}),
+		evalArg("delBatch(bytes[])", []string{"bytes[]"}, [][]byte{key}),
+		evalArg("popMin()", nil),
+		evalArg("popMax()", nil),
+	} {
+		caller := newComparatorTarget(t, key)
+		if _, ok := caller.call(true, "eval(bytes)", arg); ok {
+			t.Errorf("Expected the call %x to fail in a STATICCALL", arg[64:68])
+		}
+
+		// The element is still there and can be read in a STATICCALL.
+		if ret, ok := caller.call(true, "eval(bytes)", evalArg("getByKey(bytes)", []string{"bytes"}, key)); !ok || !bytes.Equal(ret, int256Arg(5)) {
+			t.Errorf("Expected the element to be unchanged after %x, got %v", arg[64:68], ret)
+		}
+
+		if _, ok := caller.call(false, "eval(bytes)", arg); !ok {
+			t.Errorf("Expected the call %x to succeed in a CALL", arg[64:68])
+		}
+	}
+
+	// Only the elements of the cumulative containers can be initialized.
+	caller := newHandlerCaller(NewTestEU(Coinbase, Alice), eucommon.BYTES_HANDLER)
+	if _, ok := caller.call(false, "new(uint8,bool)", packArgs([]string{"uint8", "bool"}, commutative.UINT256, false)); !ok {
+		t.Fatal("Failed to create the container")
+	}
+
+	initArg := evalArg("init(bytes,bytes,bytes)", []string{"bytes", "bytes", "bytes"}, key, int256Arg(0), int256Arg(100))
+	if _, ok := caller.call(true, "eval(bytes)", initArg); ok {
+		t.Error("Expected init to fail in a STATICCALL")
+	}
+
+	if _, ok := caller.call(false, "eval(bytes)", initArg); !ok {
+		t.Error("Expected init to succeed in a CALL")
+	}
+
+	// The containers are created in a fresh proxy each time.
+	for _, create := range []struct {
+		signature string
+		args      []byte
+	}{
+		{"new(uint8,bool)", packArgs([]string{"uint8", "bool"}, noncommutative.BYTES, false)},
+		{"newWithComparator(uint8,bool,uint8)", packArgs([]string{"uint8", "bool", "uint8"}, noncommutative.BYTES, false, apicontainer.CMP_INT256)},
+	} {
+		caller := newHandlerCaller(NewTestEU(Coinbase, Alice), eucommon.BYTES_HANDLER)
+		if _, ok := caller.call(true, create.signature, create.args); ok {
+			t.Errorf("Expected %v to fail in a STATICCALL", create.signature)
+		}
+
+		if _, ok := caller.call(false, create.signature, create.args); !ok {
+			t.Errorf("Expected %v to succeed in a CALL", create.signature)
+		}
+	}
+
+	// The cumulative variables, the mutations run in order so each CALL has the state it needs.
+	for _, test := range []struct {
+		handler [20]byte
+		typ     string
+		named   bool // Has the variables identified by a bytes32 name as well.
+	}{
+		{eucommon.CUMULATIVE_U256_HANDLER, "uint256", true},
+		{eucommon.CUMULATIVE_I256_HANDLER, "int256", false},
+		{eucommon.CUMULATIVE_U64_HANDLER, "uint64", true},
+	} {
+		mutations := []struct {
+			signature string
+			args      [][]byte
+		}{
+			{"new(" + test.typ + "," + test.typ + ")", [][]byte{int256Arg(0), int256Arg(100)}},
+			{"add(" + test.typ + ")", [][]byte{int256Arg(2)}},
+			{"sub(" + test.typ + ")", [][]byte{int256Arg(1)}},
+		}
+
+		if name := int256Arg(1); test.named {
+			mutations = append(mutations, []struct {
+				signature string
+				args      [][]byte
+			}{
+				{"new(bytes32," + test.typ + "," + test.typ + ")", [][]byte{name, int256Arg(0), int256Arg(100)}},
+				{"add(bytes32," + test.typ + ")", [][]byte{name, int256Arg(2)}},
+				{"sub(bytes32," + test.typ + ")", [][]byte{name, int256Arg(1)}},
+			}...)
+		}
+
+		cumulative := newHandlerCaller(NewTestEU(Coinbase, Alice), test.handler)
+		for _, mutation := range mutations {
+			if _, ok := cumulative.call(true, mutation.signature, mutation.args...); ok {
+				t.Errorf("Expected %v to fail in a STATICCALL on %x", mutation.signature, test.handler)
+			}
+
+			if _, ok := cumulative.call(false, mutation.signature, mutation.args...); !ok {
+				t.Errorf("Expected %v to succeed in a CALL on %x", mutation.signature, test.handler)
+			}
+		}
+
+		for _, signature := range []string{"get()", "peek()", "min()", "max()"} {
+			if _, ok := cumulative.call(true, signature); !ok {
+				t.Errorf("Expected %v to succeed in a STATICCALL on %x", signature, test.handler)
+			}
+		}
+	}
+
+	// The runtime handler, setParallelism and deferCall can only be called from a constructor.
+	word := func(b []byte) []byte { return evmcommon.RightPadBytes(b, 32) }
+	for _, data := range [][]byte{
+		// setParallelism: the function, the target, the signatures of the target with the parallelism level 1 as the count.
+		bytes.Join([][]byte{{0x0f, 0x0d, 0x97, 0xaa}, word([]byte{1, 2, 3, 4}), evmcommon.LeftPadBytes(Bob.Bytes(), 32), word(nil), int256Arg(1), word([]byte{5, 6, 7, 8})}, nil),
+		// deferCall: the function and the prepaid gas.
+		bytes.Join([][]byte{{0xac, 0x8f, 0x58, 0xf3}, word([]byte{1, 2, 3, 4}), int256Arg(0)}, nil),
+	} {
+		if deployCalling(true, eucommon.RUNTIME_HANDLER, data) {
+			t.Errorf("Expected %x to fail in a STATICCALL", data[:4])
+		}
+
+		if !deployCalling(false, eucommon.RUNTIME_HANDLER, data) {
+			t.Errorf("Expected %x to succeed in a CALL", data[:4])
+		}
+	}
+}
+
+// The read-only selectors of the containers work in a STATICCALL.
+func TestStaticCallAllowsReads(t *testing.T) {
+	key := []byte("k")
+	caller := newComparatorTarget(t, key)
+	for _, arg := range [][]byte{
+		evalArg("committedLength()", nil),
+		evalArg("fullLength()", nil),
+		evalArg("nonNilLength()", nil),
+		evalArg("keyToInd(bytes)", []string{"bytes"}, key),
+		evalArg("indToKey(uint256)", []string{"uint256"}, big.NewInt(0)),
+		evalArg("getByKey(bytes)", []string{"bytes"}, key),
+		evalArg("getByIndex(uint256)", []string{"uint256"}, big.NewInt(0)),
+		evalArg("getRange(uint256,uint256)", []string{"uint256", "uint256"}, big.NewInt(0), big.NewInt(1)),
+		evalArg("getRangeByKey(bytes,uint256)", []string{"bytes", "uint256"}, key, big.NewInt(1)),
+		evalArg("getBatch(bytes[])", []string{"bytes[]"}, [][]byte{key}),
+		evalArg("min()", nil),
+		evalArg("min(uint8)", []string{"uint8"}, apicontainer.CMP_INT256),
+		evalArg("max()", nil),
+		evalArg("max(uint8)", []string{"uint8"}, apicontainer.CMP_INT256),
+		evalArg("lowerBound(bytes)", []string{"bytes"}, int256Arg(5)),
+		evalArg("upperBound(bytes)", []string{"bytes"}, int256Arg(4)),
+		evalArg("pid()", nil),
+	} {
+		if _, ok := caller.call(true, "eval(bytes)", arg); !ok {
+			t.Errorf("Expected the call %x to succeed in a STATICCALL", arg[64:68])
+		}
+	}
+
+	if _, ok := newHandlerCaller(NewTestEU(Coinbase, Alice), eucommon.RUNTIME_HANDLER).call(true, "pid()"); !ok {
+		t.Error("Expected pid() of the runtime to succeed in a STATICCALL")
+	}
+}
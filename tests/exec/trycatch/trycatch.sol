@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: GPL-3.0
+pragma solidity ^0.8.19;
+
+contract Child {}
+
+contract Callee {
+    uint256 public value;
+    event Written(uint256 value);
+
+    function write(uint256 v) public payable {
+        value = v;
+        emit Written(v);
+    }
+
+    function writeThenRevert(uint256 v) public payable {
+        value = v;
+        emit Written(v);
+        new Child();
+        revert("reverted");
+    }
+
+    function panic() public pure {
+        assert(false);
+    }
+}
+
+contract TryCatch {
+    Callee public callee;
+    uint256 public caught;
+    uint256 public succeeded;
+
+    event Caught(string reason);
+    event Panicked(uint256 code);
+
+    constructor(Callee _callee) {
+        callee = _callee;
+    }
+
+    function run() public payable {
+        try callee.write{value: 1}(1) {
+            succeeded += 1;
+        } catch {
+            caught += 1;
+        }
+
+        try callee.writeThenRevert{value: 2}(2) {
+            succeeded += 1;
+        } catch Error(string memory reason) {
+            caught += 1;
+            emit Caught(reason);
+        }
+
+        try callee.panic() {
+            succeeded += 1;
+        } catch Panic(uint256 code) {
+            caught += 1;
+            emit Panicked(code);
+        }
+    }
+}
@@ -0,0 +1,185 @@
+/*
+ *   Copyright (c) 2025 Arcology Network
+
+ *   This program is free software: you can redistribute it and/or modify
+ *   it under the terms of the GNU General Public License as published by
+ *   the Free Software Foundation, either version 3 of the License, or
+ *   (at your option) any later version.
+
+ *   This program is distributed in the hope that it will be useful,
+ *   but WITHOUT ANY WARRANTY; without even the implied warranty of
+ *   MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ *   GNU General Public License for more details.
+
+ *   You should have received a copy of the GNU General Public License
+ *   along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package exectest
+
+import (
+	"bytes"
+	"math"
+	"math/big"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	commontype "github.com/arcology-network/common-lib/types"
+	eucommon "github.com/arcology-network/eu/common"
+	"github.com/arcology-network/eu/compiler"
+	ethimpl "github.com/arcology-network/eu/eth"
+	evmcommon "github.com/ethereum/go-ethereum/common"
+	evmcore "github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	evmtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+)
+
+// noArcologyAPIs is the API router for the EVMs running on geth's StateDB, there are no Arcology APIs to call there.
+type noArcologyAPIs struct{}
+
+func (noArcologyAPIs) Call(caller, callee [20]byte, input []byte, origin [20]byte, nonce uint64, blockhash evmcommon.Hash, isStatic bool) (bool, []byte, bool, int64) {
+	return false, []byte{}, true, 0
+}
+
+func (noArcologyAPIs) Job() any                                  { return nil }
+func (noArcologyAPIs) PrepayGas(*uint64, *uint64) (uint64, bool) { return 0, true }
+func (noArcologyAPIs) UsePrepaidGas(*uint64) bool                { return false }
+func (noArcologyAPIs) RefundPrepaidGas(*uint64) bool             { return false }
+func (noArcologyAPIs) SetExecutionErr(error)                     {}
+
+// runOnImplStateDB executes the messages one by one on the ImplStateDB, it returns the receipts and a StateDB to read the states.
+func runOnImplStateDB(t *testing.T, testEu *TestEu, msgs []evmcore.Message) ([]*evmtypes.Receipt, vm.StateDB) {
+	receipts := make([]*evmtypes.Receipt, len(msgs))
+	for i := range msgs {
+		job := &eucommon.Job{StdMsg: &commontype.StandardMessage{ID: uint64(i + 1), TxHash: evmcommon.Hash{byte(i + 1)}, Native: &msgs[i]}}
+
+		receipt, _, err := testEu.eu.Run(job, eucommon.NewEVMBlockContext(testEu.config), eucommon.NewEVMTxContext(msgs[i]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		receipts[i] = receipt
+	}
+
+	statedb := ethimpl.NewImplStateDB(testEu.eu.Api())
+	statedb.PrepareFormer(evmcommon.Hash{}, evmcommon.Hash{}, uint64(len(msgs)+1))
+	return receipts, statedb
+}
+
+// runOnGeth executes the messages one by one on geth's StateDB, with Alice funded the same way as in NewTestEU().
+func runOnGeth(t *testing.T, config *eucommon.Config, msgs []evmcore.Message) ([]*evmtypes.Receipt, vm.StateDB) {
+	statedb, err := state.New(evmtypes.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statedb.CreateAccount(Alice)
+	statedb.AddBalance(Alice, uint256.NewInt(1e18))
+	statedb.Finalise(true)
+
+	receipts := make([]*evmtypes.Receipt, len(msgs))
+	for i := range msgs {
+		hash := evmcommon.Hash{byte(i + 1)}
+		statedb.SetTxContext(hash, i)
+
+		evm := vm.NewEVM(eucommon.NewEVMBlockContext(config), eucommon.NewEVMTxContext(msgs[i]), statedb, config.ChainConfig, *config.VMConfig)
+		evm.ArcologyAPIs.APIs = noArcologyAPIs{}
+
+		gasPool := evmcore.GasPool(math.MaxUint64)
+		result, err := evmcore.ApplyMessage(evm, &msgs[i], &gasPool)
+		if err != nil {
+			t.Fatal(err)
+		}
+		statedb.Finalise(true)
+
+		receipts[i] = evmtypes.NewReceipt(nil, result.Failed(), result.UsedGas)
+		receipts[i].GasUsed = result.UsedGas
+		receipts[i].ContractAddress = result.ContractAddress
+		receipts[i].Logs = statedb.GetLogs(hash, config.BlockNumber.Uint64(), evmcommon.Hash{})
+	}
+	return receipts, statedb
+}
+
+// Alice deploys the Callee and the TryCatch contracts, then calls TryCatch.run(), which calls the Callee three times in
+// try/catch blocks: one call succeeds, one reverts with a reason after writing the storage, emitting a log and creating
+// a contract, and one panics. The results must be the same on the ImplStateDB and on geth's own StateDB.
+func TestTryCatchAgainstGethStateDB(t *testing.T) {
+	targetPath, _ := filepath.Abs("./trycatch")
+	calleeCode, err := compiler.CompileContracts(targetPath, "trycatch.sol", "0.8.19", "Callee", false)
+	if err != nil {
+		t.Skip("Failed to compile the contracts, solc is unavailable:", err)
+	}
+
+	tryCatchCode, err := compiler.CompileContracts(targetPath, "trycatch.sol", "0.8.19", "TryCatch", false)
+	if err != nil {
+		t.Skip("Failed to compile the contracts, solc is unavailable:", err)
+	}
+
+	callee, tryCatch := crypto.CreateAddress(Alice, 0), crypto.CreateAddress(Alice, 1)
+	newMsgs := func() []evmcore.Message {
+		return []evmcore.Message{
+			evmcore.NewMessage(Alice, nil, 0, new(big.Int), 1e7, big.NewInt(1), evmcommon.Hex2Bytes(calleeCode), nil, false),
+			evmcore.NewMessage(Alice, nil, 1, new(big.Int), 1e7, big.NewInt(1), append(evmcommon.Hex2Bytes(tryCatchCode), evmcommon.LeftPadBytes(callee.Bytes(), 32)...), nil, false),
+			evmcore.NewMessage(Alice, &tryCatch, 2, big.NewInt(3), 1e7, big.NewInt(1), crypto.Keccak256([]byte("run()"))[:4], nil, false),
+		}
+	}
+
+	// Each storage slot is only written by one transaction, so nothing needs to be committed between the transactions
+	// for the SSTORE gas to match.
+	testEu := NewTestEU(Coinbase, Alice)
+	arcReceipts, arcState := runOnImplStateDB(t, testEu, newMsgs())
+	ethReceipts, ethState := runOnGeth(t, testEu.config, newMsgs())
+
+	for i := range ethReceipts {
+		arc, eth := arcReceipts[i], ethReceipts[i]
+		if arc.Status != eth.Status || arc.GasUsed != eth.GasUsed || arc.ContractAddress != eth.ContractAddress {
+			t.Errorf("Tx %d: expected (status, gas, contract) = (%v, %v, %v), got (%v, %v, %v)", i, eth.Status, eth.GasUsed, eth.ContractAddress, arc.Status, arc.GasUsed, arc.ContractAddress)
+		}
+
+		if len(arc.Logs) != len(eth.Logs) {
+			t.Fatalf("Tx %d: expected %d logs, got %d", i, len(eth.Logs), len(arc.Logs))
+		}
+
+		for j := range eth.Logs {
+			if arc.Logs[j].Address != eth.Logs[j].Address || !slices.Equal(arc.Logs[j].Topics, eth.Logs[j].Topics) || !bytes.Equal(arc.Logs[j].Data, eth.Logs[j].Data) {
+				t.Errorf("Tx %d: log %d is different, expected %v, got %v", i, j, eth.Logs[j], arc.Logs[j])
+			}
+		}
+	}
+
+	// Written(1) from the successful call, Caught(string) and Panicked(uint256), the Written(2) in the reverted call is gone.
+	if len(ethReceipts[2].Logs) != 3 {
+		t.Error("Expected 3 logs from run(), got", len(ethReceipts[2].Logs))
+	}
+
+	// TryCatch: callee, caught, succeeded. Callee: value.
+	if v := ethState.GetState(tryCatch, evmcommon.BigToHash(big.NewInt(1))); v != evmcommon.BigToHash(big.NewInt(2)) {
+		t.Error("Expected 2 calls to be caught, got", v)
+	}
+
+	for _, slot := range []struct {
+		account evmcommon.Address
+		key     int64
+	}{{tryCatch, 0}, {tryCatch, 1}, {tryCatch, 2}, {callee, 0}} {
+		key := evmcommon.BigToHash(big.NewInt(slot.key))
+		if arc, eth := arcState.GetState(slot.account, key), ethState.GetState(slot.account, key); arc != eth {
+			t.Errorf("Slot %d of %v: expected %v, got %v", slot.key, slot.account, eth, arc)
+		}
+	}
+
+	for _, account := range []evmcommon.Address{Alice, Coinbase, tryCatch, callee} {
+		if arc, eth := arcState.GetBalance(account), ethState.GetBalance(account); !arc.Eq(eth) {
+			t.Errorf("Balance of %v: expected %v, got %v", account, eth, arc)
+		}
+	}
+
+	// Known difference: only the nonce of Alice is compared. The nonces are commutative increments in the ImplStateDB,
+	// they can't go down, so the increment from the CREATE in the reverted writeThenRevert() call stays, while geth
+	// reverts it. The nonces of the contracts are offset as well, see CalculateNonceOffset().
+	if arc, eth := arcState.GetNonce(Alice), ethState.GetNonce(Alice); arc != eth {
+		t.Errorf("Nonce of Alice: expected %v, got %v", eth, arc)
+	}
+}